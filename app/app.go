@@ -1,7 +1,6 @@
 package app
 
 import (
-	"bufio"
 	"context"
 	"course_project/pkg/parsing"
 	"course_project/pkg/sending"
@@ -12,6 +11,7 @@ import (
 	"os"
 	"os/exec"
 	"os/signal"
+	"path/filepath"
 	"strings"
 	"syscall"
 	"time"
@@ -21,8 +21,9 @@ import (
 
 const (
 	messageClient = "Введите запрос в формате:\n\n" +
-		"	'SELECT * (или поля через запятую) FROM имя_csv_файла WHERE column_name OP 'example' [AND/OR column_name OP 5]';\n\n" +
-		"В конце обязательно поставьте ';'\n\n "
+		"	'SELECT * (или поля через запятую) FROM имя_csv_файла [WHERE column_name OP 'example' [AND/OR column_name OP 5]]';\n\n" +
+		"В конце обязательно поставьте ';'. Можно ввести несколько запросов через ';',\n" +
+		"они выполнятся по очереди, каждый в свой файл с результатом. Завершите ввод Ctrl+D\n\n "
 
 	modeAppend = 0644
 )
@@ -89,9 +90,23 @@ func (a *App) getLastVersionCommit() error {
 }
 
 func (a *App) LogAccess(request string) {
+	a.logAccess(fmt.Sprintf("request: \"%s\"", request))
+}
+
+// LogStatement writes the statement of a script to the access log with the comments before it.
+func (a *App) LogStatement(stmt *parsing.Statement) {
+	entry := fmt.Sprintf("request: \"%s\"", stmt.Source)
+	if len(stmt.Comments) != 0 {
+		entry = fmt.Sprintf("comment: \"%s\", %s", strings.Join(stmt.Comments, "; "), entry)
+	}
+
+	a.logAccess(entry)
+}
+
+func (a *App) logAccess(entry string) {
 	filePath := a.Config.GetFilePathAccessLog()
 
-	message := fmt.Sprintf("time: \"%s\", request: \"%s\"\n", time.Now().Format("02.01.2006 15:04:05"), request)
+	message := fmt.Sprintf("time: \"%s\", %s\n", time.Now().Format("02.01.2006 15:04:05"), entry)
 	if err := a.writeToFile(filePath, message); err != nil {
 		a.LogError(err)
 	}
@@ -152,63 +167,81 @@ func (a *App) Run() {
 
 	p := parsing.NewParser()
 
-	request, err := a.getRequestFromClient()
+	script, err := a.getRequestFromClient()
 	if err != nil {
 		a.LogError(err)
 		return
 	}
-	a.LogAccess(request)
 
-	sel, err := p.Parse(request)
+	statements, err := p.ParseScript(script)
 	if err != nil {
+		a.LogAccess(script)
 		a.LogError(err)
 		return
 	}
 
-	s, err := sending.New(a.Config.GetCsvFilePath())
+	s, err := sending.New(a.Config.GetCsvFilePath(), a.Config.GetNullValues(), a.Config.GetDateColumns())
 	if err != nil {
 		a.LogError(err)
 		return
 	}
 
+	// statements run one by one, the script stops at the first failed one
+	for idx, stmt := range statements {
+		a.LogStatement(stmt)
+
+		err = a.runStatement(s, stmt.Select, a.resultFilePath(idx, len(statements)))
+		if err != nil {
+			a.LogError(err)
+			return
+		}
+	}
+}
+
+func (a *App) runStatement(s *sending.CsvParser, sel *parsing.SelectStatement, resultPath string) error {
 	res, err := s.SendRequest(sel)
 	if err != nil {
-		a.LogError(err)
-		return
+		return err
 	}
 
-	err = a.removeOldResultFileCsv()
+	err = a.removeOldResultFileCsv(resultPath)
 	if err != nil {
-		a.LogError(err)
-		return
+		return err
 	}
 
-	err = a.writeResultToCsv(res)
+	err = a.writeResultToCsv(res, resultPath)
 	if err != nil {
-		a.LogError(err)
-		return
+		return err
+	}
+
+	fmt.Println("\ncount: ", len(res), " result in: ", resultPath)
+	return nil
+}
+
+// resultFilePath returns the result file of the statement idx, for a script of many statements
+// the files are numbered: result_1.csv, result_2.csv and so on.
+func (a *App) resultFilePath(idx, count int) string {
+	path := a.Config.FilePathResultCsv
+	if count == 1 {
+		return path
 	}
 
-	fmt.Println("\ncount: ", len(res), " result in: ", a.Config.FilePathResultCsv)
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s_%d%s", strings.TrimSuffix(path, ext), idx+1, ext)
 }
 
+// getRequestFromClient reads the script until the end of input, the parser splits it into statements
+// and rejects text after the last ';' unless it is a comment.
 func (a *App) getRequestFromClient() (string, error) {
 	fmt.Println(messageClient)
 
-	in := bufio.NewReader(os.Stdin)
-
-	request, err := in.ReadString('\n')
+	data, err := ioutil.ReadAll(os.Stdin)
 	if err != nil {
-		return request, err
-	}
-
-	i := strings.LastIndex(request, ";")
-	if i == -1 {
-		return "", fmt.Errorf("expected ';'")
+		return "", err
 	}
 
-	request = request[:i+1]
-	return request, nil
+	// statements end where the parser finds ';', a ';' inside a comment or a string does not end them
+	return string(data), nil
 }
 
 func (a *App) exit(ctx context.Context) {
@@ -217,12 +250,12 @@ func (a *App) exit(ctx context.Context) {
 	log.Fatal("exit")
 }
 
-func (a *App) removeOldResultFileCsv() error {
-	if _, err := os.Stat(a.Config.FilePathResultCsv); os.IsExist(err) {
+func (a *App) removeOldResultFileCsv(path string) error {
+	if _, err := os.Stat(path); os.IsExist(err) {
 		if err != nil {
 			return err
 		}
-		err = os.Remove(a.Config.FilePathResultCsv)
+		err = os.Remove(path)
 		if err != nil {
 			return err
 		}
@@ -230,8 +263,8 @@ func (a *App) removeOldResultFileCsv() error {
 	return nil
 }
 
-func (a *App) writeResultToCsv(result [][]string) error {
-	outfile, err := os.Create(a.Config.FilePathResultCsv)
+func (a *App) writeResultToCsv(result [][]string, path string) error {
+	outfile, err := os.Create(path)
 	if err != nil {
 		return fmt.Errorf("Unable to open output: %s", err)
 	}
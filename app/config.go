@@ -13,6 +13,9 @@ type Config struct {
 	FilePathErrorLog  string        `yaml:"filePathErrorLog"`
 	FilePathCsv       string        `yaml:"filePathCsv"`
 	FilePathResultCsv string        `yaml:"filePathResultCsv"`
+	NullValues        []string      `yaml:"nullValues"`
+	// DateColumns are formats of columns read as dates, like period: "YYYY.MM"
+	DateColumns map[string]string `yaml:"dateColumns"`
 }
 
 func NewConfig() *Config {
@@ -27,6 +30,14 @@ func (c *Config) GetCsvFilePath() string {
 	return c.FilePathCsv
 }
 
+func (c *Config) GetNullValues() []string {
+	return c.NullValues
+}
+
+func (c *Config) GetDateColumns() map[string]string {
+	return c.DateColumns
+}
+
 func (c *Config) ParseConfig(configPath string) error {
 	var data []byte
 
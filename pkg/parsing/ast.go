@@ -0,0 +1,532 @@
+package parsing
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Statement is one statement of a script, Source is its text up to ';'.
+type Statement struct {
+	Select *SelectStatement
+	Source string
+	// Comments are texts of the comments before the statement
+	Comments []string
+}
+
+type SelectStatement struct {
+	Item []*Expression
+	From Token
+	// FromSubquery is set instead of From for 'FROM (SELECT ...) alias'
+	FromSubquery *SelectStatement
+	FromAlias    string
+	Joins        []*Join
+	Where        *Expression
+	GroupBy      []*Expression
+	Having       *Expression
+	OrderBy      []*OrderItem
+	Limit        int
+	Offset       int
+	IsLimited    bool
+	IsAllItems   bool
+	Distinct     bool
+	DistinctOn   []*Expression
+	// Compound is set for 'SELECT ... UNION SELECT ...', only OrderBy and the limit of
+	// the statement are used then, they are applied to the combined rows
+	Compound *Compound
+	With     *With
+}
+
+// With is 'WITH [RECURSIVE] name [(column, ...)] AS (SELECT ...), ...' before a statement.
+type With struct {
+	Recursive bool
+	Tables    []*CommonTable
+}
+
+// CommonTable is a query of WITH, Columns rename the columns of its result.
+type CommonTable struct {
+	Name    string
+	Columns []string
+	Select  *SelectStatement
+}
+
+// Compound is 'Left UNION | INTERSECT | EXCEPT [ALL] Right'.
+type Compound struct {
+	Kind  SetOperationKind
+	All   bool
+	Left  *SelectStatement
+	Right *SelectStatement
+}
+
+// Join is '[INNER | LEFT | RIGHT | FULL | CROSS] JOIN Table [alias] [ON On]', On is nil for CROSS JOIN.
+// Subquery is set instead of Table for 'JOIN (SELECT ...) alias'.
+type Join struct {
+	Kind     JoinKind
+	Table    Token
+	Subquery *SelectStatement
+	Alias    string
+	On       *Expression
+}
+
+type OrderItem struct {
+	Exp        *Expression
+	Desc       bool
+	NullsFirst bool
+}
+
+type Expression struct {
+	Literal  *Token
+	Binary   *BinaryExpression
+	Unary    *UnaryExpression
+	List     []*Expression
+	Between  *BetweenExpression
+	Function *FunctionCall
+	Like     *LikeExpression
+	Case     *CaseExpression
+	// Subquery is '(SELECT ...)' of a scalar subquery, IN or EXISTS
+	Subquery *SelectStatement
+	Kind     ExpressionKind
+	// Alias is set for select list items, it is not a part of String()
+	Alias string
+}
+
+type BinaryExpression struct {
+	A  *Expression
+	B  *Expression
+	Op Token
+}
+
+type UnaryExpression struct {
+	Operand *Expression
+	Op      Token
+}
+
+type BetweenExpression struct {
+	A    *Expression
+	Low  *Expression
+	High *Expression
+}
+
+// LikeExpression is 'A LIKE Pattern [ESCAPE Escape]', Op is LIKE or ILIKE.
+type LikeExpression struct {
+	A       *Expression
+	Pattern *Expression
+	Escape  *Expression
+	Op      Token
+}
+
+// CaseExpression is 'CASE [Operand] WHEN ... THEN ... [ELSE Else] END', without Operand
+// the WHEN expressions are conditions, otherwise they are compared with Operand.
+type CaseExpression struct {
+	Operand *Expression
+	Whens   []*WhenClause
+	Else    *Expression
+}
+
+type WhenClause struct {
+	When   *Expression
+	Result *Expression
+}
+
+type FunctionCall struct {
+	Name      Token
+	Args      []*Expression
+	Distinct  bool
+	IsAllArgs bool
+	// Over is set for window functions
+	Over *Window
+}
+
+// Window is 'OVER (PARTITION BY ... ORDER BY ... frame)', Frame is nil for the default one.
+type Window struct {
+	PartitionBy []*Expression
+	OrderBy     []*OrderItem
+	Frame       *Frame
+}
+
+// Frame is 'ROWS | RANGE BETWEEN Start AND End', without BETWEEN End is CURRENT ROW.
+type Frame struct {
+	IsRange bool
+	Start   FrameBound
+	End     FrameBound
+}
+
+// FrameBound is a bound of a frame, Offset is the number of rows, or the difference of
+// values for RANGE, of 'Offset PRECEDING' and 'Offset FOLLOWING'.
+type FrameBound struct {
+	Kind   FrameBoundKind
+	Offset int
+}
+
+func (e *Expression) Children() []*Expression {
+	switch e.Kind {
+	case BinaryKind:
+		return []*Expression{e.Binary.A, e.Binary.B}
+	case UnaryKind:
+		return []*Expression{e.Unary.Operand}
+	case ListKind:
+		return e.List
+	case BetweenKind:
+		return []*Expression{e.Between.A, e.Between.Low, e.Between.High}
+	case FunctionKind:
+		if e.Function.Over == nil {
+			return e.Function.Args
+		}
+
+		children := append([]*Expression{}, e.Function.Args...)
+		children = append(children, e.Function.Over.PartitionBy...)
+		for _, item := range e.Function.Over.OrderBy {
+			children = append(children, item.Exp)
+		}
+
+		return children
+	case LikeKind:
+		if e.Like.Escape != nil {
+			return []*Expression{e.Like.A, e.Like.Pattern, e.Like.Escape}
+		}
+
+		return []*Expression{e.Like.A, e.Like.Pattern}
+	case CaseKind:
+		var children []*Expression
+		if e.Case.Operand != nil {
+			children = append(children, e.Case.Operand)
+		}
+		for _, when := range e.Case.Whens {
+			children = append(children, when.When, when.Result)
+		}
+		if e.Case.Else != nil {
+			children = append(children, e.Case.Else)
+		}
+
+		return children
+	}
+
+	return nil
+}
+
+func (e *Expression) String() string {
+	switch e.Kind {
+	case LiteralKind:
+		switch e.Literal.Kind {
+		case StringKind:
+			return fmt.Sprintf("'%s'", e.Literal.Value)
+		case DateKind:
+			return fmt.Sprintf("date '%s'", e.Literal.Value)
+		case TimestampKind:
+			return fmt.Sprintf("timestamp '%s'", e.Literal.Value)
+		}
+
+		return e.Literal.Value
+	case BinaryKind:
+		return fmt.Sprintf("(%s %s %s)", e.Binary.A.String(), e.Binary.Op.Value, e.Binary.B.String())
+	case UnaryKind:
+		if e.Unary.Op.Kind == OperationKind {
+			return fmt.Sprintf("(%s%s)", e.Unary.Op.Value, e.Unary.Operand.String())
+		}
+
+		return fmt.Sprintf("(%s %s)", e.Unary.Op.Value, e.Unary.Operand.String())
+	case ListKind:
+		items := make([]string, 0, len(e.List))
+		for _, item := range e.List {
+			items = append(items, item.String())
+		}
+
+		return fmt.Sprintf("(%s)", strings.Join(items, ", "))
+	case BetweenKind:
+		return fmt.Sprintf("(%s between %s and %s)", e.Between.A.String(), e.Between.Low.String(), e.Between.High.String())
+	case FunctionKind:
+		return e.Function.String()
+	case LikeKind:
+		if e.Like.Escape != nil {
+			return fmt.Sprintf("(%s %s %s escape %s)", e.Like.A.String(), e.Like.Op.Value, e.Like.Pattern.String(), e.Like.Escape.String())
+		}
+
+		return fmt.Sprintf("(%s %s %s)", e.Like.A.String(), e.Like.Op.Value, e.Like.Pattern.String())
+	case CaseKind:
+		return e.Case.String()
+	case SubqueryKind:
+		return fmt.Sprintf("(%s)", e.Subquery.String())
+	case ExistsKind:
+		return fmt.Sprintf("exists (%s)", e.Subquery.String())
+	}
+
+	return ""
+}
+
+func (c *CaseExpression) String() string {
+	parts := []string{"case"}
+	if c.Operand != nil {
+		parts = append(parts, c.Operand.String())
+	}
+
+	for _, when := range c.Whens {
+		parts = append(parts, "when", when.When.String(), "then", when.Result.String())
+	}
+
+	if c.Else != nil {
+		parts = append(parts, "else", c.Else.String())
+	}
+
+	return strings.Join(append(parts, "end"), " ")
+}
+
+func (f *FunctionCall) String() string {
+	call := f.callString()
+	if f.Over != nil {
+		call += fmt.Sprintf(" over (%s)", f.Over)
+	}
+
+	return call
+}
+
+// callString writes the call without its OVER clause.
+func (f *FunctionCall) callString() string {
+	if f.IsAllArgs {
+		return fmt.Sprintf("%s(*)", f.Name.Value)
+	}
+
+	args := make([]string, 0, len(f.Args))
+	for _, arg := range f.Args {
+		args = append(args, arg.String())
+	}
+
+	// EXTRACT(year FROM period) is written as it is parsed, see parseExtract
+	isExtract := f.Name.Value == "extract" && len(f.Args) == 2 && f.Args[0].Kind == LiteralKind && f.Args[0].Literal.Kind == StringKind
+	if isExtract {
+		return fmt.Sprintf("extract(%s from %s)", f.Args[0].Literal.Value, f.Args[1])
+	}
+
+	distinct := ""
+	if f.Distinct {
+		distinct = "distinct "
+	}
+
+	return fmt.Sprintf("%s(%s%s)", f.Name.Value, distinct, strings.Join(args, ", "))
+}
+
+func (w *Window) String() string {
+	var parts []string
+	if len(w.PartitionBy) != 0 {
+		items := make([]string, 0, len(w.PartitionBy))
+		for _, item := range w.PartitionBy {
+			items = append(items, item.String())
+		}
+		parts = append(parts, "partition by "+strings.Join(items, ", "))
+	}
+
+	if len(w.OrderBy) != 0 {
+		items := make([]string, 0, len(w.OrderBy))
+		for _, item := range w.OrderBy {
+			items = append(items, item.String())
+		}
+		parts = append(parts, "order by "+strings.Join(items, ", "))
+	}
+
+	if w.Frame != nil {
+		parts = append(parts, w.Frame.String())
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func (f *Frame) String() string {
+	mode := "rows"
+	if f.IsRange {
+		mode = "range"
+	}
+
+	return fmt.Sprintf("%s between %s and %s", mode, f.Start, f.End)
+}
+
+func (b FrameBound) String() string {
+	switch b.Kind {
+	case UnboundedPreceding:
+		return "unbounded preceding"
+	case Preceding:
+		return fmt.Sprintf("%d preceding", b.Offset)
+	case Following:
+		return fmt.Sprintf("%d following", b.Offset)
+	case UnboundedFollowing:
+		return "unbounded following"
+	}
+
+	return "current row"
+}
+
+func (s *SelectStatement) String() string {
+	if s.With != nil {
+		with := *s
+		with.With = nil
+
+		return fmt.Sprintf("%s %s", s.With, &with)
+	}
+
+	if s.Compound != nil {
+		return s.Compound.String() + s.tailString()
+	}
+
+	parts := []string{"select"}
+	if s.Distinct {
+		parts = append(parts, "distinct")
+	}
+	if len(s.DistinctOn) != 0 {
+		parts = append(parts, fmt.Sprintf("distinct on %s", (&Expression{List: s.DistinctOn, Kind: ListKind}).String()))
+	}
+
+	if s.IsAllItems {
+		parts = append(parts, "*")
+	} else {
+		items := make([]string, 0, len(s.Item))
+		for _, item := range s.Item {
+			if item.Alias != "" {
+				items = append(items, fmt.Sprintf("%s as %s", item, item.Alias))
+				continue
+			}
+
+			items = append(items, item.String())
+		}
+		parts = append(parts, strings.Join(items, ", "))
+	}
+
+	if s.From.Value != "" || s.FromSubquery != nil {
+		parts = append(parts, "from", fromString(s.From, s.FromSubquery, s.FromAlias))
+	}
+
+	for _, join := range s.Joins {
+		parts = append(parts, join.String())
+	}
+
+	if s.Where != nil {
+		parts = append(parts, "where", s.Where.String())
+	}
+
+	if len(s.GroupBy) != 0 {
+		items := make([]string, 0, len(s.GroupBy))
+		for _, item := range s.GroupBy {
+			items = append(items, item.String())
+		}
+		parts = append(parts, "group by", strings.Join(items, ", "))
+	}
+
+	if s.Having != nil {
+		parts = append(parts, "having", s.Having.String())
+	}
+
+	return strings.Join(parts, " ") + s.tailString()
+}
+
+// tailString returns ORDER BY and the limit with a leading space.
+func (s *SelectStatement) tailString() string {
+	var parts []string
+	if len(s.OrderBy) != 0 {
+		items := make([]string, 0, len(s.OrderBy))
+		for _, item := range s.OrderBy {
+			items = append(items, item.String())
+		}
+		parts = append(parts, "order by", strings.Join(items, ", "))
+	}
+
+	if s.IsLimited {
+		parts = append(parts, fmt.Sprintf("limit %d", s.Limit))
+	}
+	if s.Offset != 0 {
+		parts = append(parts, fmt.Sprintf("offset %d", s.Offset))
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+
+	return " " + strings.Join(parts, " ")
+}
+
+func (c *Compound) String() string {
+	kinds := map[SetOperationKind]string{
+		Union:     "union",
+		Intersect: "intersect",
+		Except:    "except",
+	}
+
+	op := kinds[c.Kind]
+	if c.All {
+		op += " all"
+	}
+
+	return fmt.Sprintf("%s %s %s", c.Left.operandString(), op, c.Right.operandString())
+}
+
+// operandString writes an operand of UNION and others in parentheses when it is
+// a compound or has its own ORDER BY or limit.
+func (s *SelectStatement) operandString() string {
+	if s.Compound != nil || s.tailString() != "" {
+		return fmt.Sprintf("(%s)", s)
+	}
+
+	return s.String()
+}
+
+func (j *Join) String() string {
+	kinds := map[JoinKind]string{
+		InnerJoin: "join",
+		LeftJoin:  "left join",
+		RightJoin: "right join",
+		FullJoin:  "full join",
+		CrossJoin: "cross join",
+	}
+
+	join := fmt.Sprintf("%s %s", kinds[j.Kind], fromString(j.Table, j.Subquery, j.Alias))
+	if j.On != nil {
+		join += fmt.Sprintf(" on %s", j.On)
+	}
+
+	return join
+}
+
+func fromString(table Token, subquery *SelectStatement, alias string) string {
+	from := table.Value
+	if subquery != nil {
+		from = fmt.Sprintf("(%s)", subquery)
+	}
+
+	if alias != "" {
+		from += " " + alias
+	}
+
+	return from
+}
+
+// String writes the default NULLS order only when it is changed.
+func (o *OrderItem) String() string {
+	item := o.Exp.String()
+	if o.Desc {
+		item += " desc"
+	}
+
+	if o.NullsFirst != o.Desc {
+		if o.NullsFirst {
+			item += " nulls first"
+		} else {
+			item += " nulls last"
+		}
+	}
+
+	return item
+}
+
+func (w *With) String() string {
+	tables := make([]string, 0, len(w.Tables))
+	for _, table := range w.Tables {
+		name := table.Name
+		if len(table.Columns) != 0 {
+			name += fmt.Sprintf("(%s)", strings.Join(table.Columns, ", "))
+		}
+
+		tables = append(tables, fmt.Sprintf("%s as (%s)", name, table.Select))
+	}
+
+	with := "with "
+	if w.Recursive {
+		with += "recursive "
+	}
+
+	return with + strings.Join(tables, ", ")
+}
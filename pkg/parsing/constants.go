@@ -3,11 +3,70 @@ package parsing
 type Keyword string
 
 const (
-	SelectKeyword Keyword = "select"
-	WhereKeyword  Keyword = "where"
-	FromKeyword   Keyword = "from"
-	AndKeyword    Keyword = "and"
-	OrKeyword     Keyword = "or"
+	SelectKeyword   Keyword = "select"
+	WhereKeyword    Keyword = "where"
+	FromKeyword     Keyword = "from"
+	AndKeyword      Keyword = "and"
+	OrKeyword       Keyword = "or"
+	NotKeyword      Keyword = "not"
+	InKeyword       Keyword = "in"
+	LikeKeyword     Keyword = "like"
+	BetweenKeyword  Keyword = "between"
+	OrderKeyword    Keyword = "order"
+	ByKeyword       Keyword = "by"
+	AscKeyword      Keyword = "asc"
+	DescKeyword     Keyword = "desc"
+	NullsKeyword    Keyword = "nulls"
+	FirstKeyword    Keyword = "first"
+	LastKeyword     Keyword = "last"
+	LimitKeyword    Keyword = "limit"
+	OffsetKeyword   Keyword = "offset"
+	FetchKeyword    Keyword = "fetch"
+	NextKeyword     Keyword = "next"
+	RowKeyword      Keyword = "row"
+	RowsKeyword     Keyword = "rows"
+	OnlyKeyword     Keyword = "only"
+	GroupKeyword    Keyword = "group"
+	DistinctKeyword Keyword = "distinct"
+	HavingKeyword   Keyword = "having"
+	OnKeyword       Keyword = "on"
+	AsKeyword       Keyword = "as"
+	IlikeKeyword    Keyword = "ilike"
+	EscapeKeyword   Keyword = "escape"
+	IsKeyword       Keyword = "is"
+	NullKeyword     Keyword = "null"
+	RegexpKeyword   Keyword = "regexp"
+	CaseKeyword     Keyword = "case"
+	WhenKeyword     Keyword = "when"
+	ThenKeyword     Keyword = "then"
+	ElseKeyword     Keyword = "else"
+	EndKeyword      Keyword = "end"
+	JoinKeyword     Keyword = "join"
+	InnerKeyword    Keyword = "inner"
+	LeftKeyword     Keyword = "left"
+	RightKeyword    Keyword = "right"
+	FullKeyword     Keyword = "full"
+	OuterKeyword    Keyword = "outer"
+	CrossKeyword    Keyword = "cross"
+	ExistsKeyword   Keyword = "exists"
+
+	// set operations
+	UnionKeyword     Keyword = "union"
+	IntersectKeyword Keyword = "intersect"
+	ExceptKeyword    Keyword = "except"
+	AllKeyword       Keyword = "all"
+
+	WithKeyword      Keyword = "with"
+	RecursiveKeyword Keyword = "recursive"
+
+	// window functions
+	OverKeyword      Keyword = "over"
+	PartitionKeyword Keyword = "partition"
+	RangeKeyword     Keyword = "range"
+	UnboundedKeyword Keyword = "unbounded"
+	PrecedingKeyword Keyword = "preceding"
+	FollowingKeyword Keyword = "following"
+	CurrentKeyword   Keyword = "current"
 )
 
 type TokenKind uint
@@ -19,14 +78,22 @@ const (
 	StringKind
 	NumericKind
 	OperationKind
+	// CommentKind tokens are not parsed, they are kept as comments of statements
+	CommentKind
+	// DateKind and TimestampKind are literals DATE '2016-06-01' and TIMESTAMP '...',
+	// they are made by the parser from an identifier and a string
+	DateKind
+	TimestampKind
 )
 
 type symbol string
 
 const (
-	commaSymbol     symbol = ","
-	semicolonSymbol symbol = ";"
-	allFields       symbol = "*"
+	commaSymbol      symbol = ","
+	semicolonSymbol  symbol = ";"
+	allFields        symbol = "*"
+	leftParenSymbol  symbol = "("
+	rightParenSymbol symbol = ")"
 )
 
 type Operation string
@@ -38,4 +105,64 @@ const (
 	LessEqualOperation Operation = "<="
 	EqualsOperation    Operation = "="
 	NotEqualOperation  Operation = "!="
+
+	NotEqualAliasOperation Operation = "<>"
+
+	PlusOperation   Operation = "+"
+	MinusOperation  Operation = "-"
+	DivideOperation Operation = "/"
+	ModuloOperation Operation = "%"
+	// MultiplyOperation is lexed as allFields symbol
+	MultiplyOperation Operation = "*"
+
+	ConcatOperation Operation = "||"
+
+	RegexpOperation               Operation = "~"
+	RegexpInsensitiveOperation    Operation = "~*"
+	NotRegexpOperation            Operation = "!~"
+	NotRegexpInsensitiveOperation Operation = "!~*"
+)
+
+type JoinKind uint
+
+const (
+	InnerJoin JoinKind = iota
+	LeftJoin
+	RightJoin
+	FullJoin
+	CrossJoin
+)
+
+type SetOperationKind uint
+
+const (
+	Union SetOperationKind = iota
+	Intersect
+	Except
+)
+
+type FrameBoundKind uint
+
+// frame bounds are in the order of rows they refer to
+const (
+	UnboundedPreceding FrameBoundKind = iota
+	Preceding
+	CurrentRow
+	Following
+	UnboundedFollowing
+)
+
+type ExpressionKind uint
+
+const (
+	LiteralKind ExpressionKind = iota
+	BinaryKind
+	UnaryKind
+	ListKind
+	BetweenKind
+	FunctionKind
+	LikeKind
+	CaseKind
+	SubqueryKind
+	ExistsKind
 )
@@ -0,0 +1,785 @@
+package parsing
+
+import (
+	"fmt"
+	"regexp"
+)
+
+const (
+	orBindingPower uint = iota + 1
+	andBindingPower
+	notBindingPower
+	comparisonBindingPower
+	concatBindingPower
+	additiveBindingPower
+	multiplicativeBindingPower
+	unaryBindingPower
+)
+
+func (t *Token) bindingPower() uint {
+	switch t.Kind {
+	case KeywordKind:
+		switch Keyword(t.Value) {
+		case OrKeyword:
+			return orBindingPower
+		case AndKeyword:
+			return andBindingPower
+		case NotKeyword, InKeyword, LikeKeyword, IlikeKeyword, BetweenKeyword, IsKeyword, RegexpKeyword:
+			return comparisonBindingPower
+		}
+	case OperationKind:
+		switch Operation(t.Value) {
+		case PlusOperation, MinusOperation:
+			return additiveBindingPower
+		case DivideOperation, ModuloOperation:
+			return multiplicativeBindingPower
+		case ConcatOperation:
+			return concatBindingPower
+		}
+
+		return comparisonBindingPower
+	case SymbolKind:
+		if symbol(t.Value) == allFields {
+			return multiplicativeBindingPower
+		}
+	}
+
+	return 0
+}
+
+func (p *Parser) parseInfixOperator(initialCursor uint) (*Token, bool) {
+	if initialCursor >= uint(len(p.tokens)) {
+		return nil, false
+	}
+
+	current := p.tokens[initialCursor]
+	if current.Kind == OperationKind || p.expectToken(initialCursor, p.tokenFromSymbol(allFields)) {
+		return current, true
+	}
+
+	infixKeywords := []Keyword{
+		AndKeyword, OrKeyword, NotKeyword, InKeyword, LikeKeyword, IlikeKeyword, BetweenKeyword, IsKeyword, RegexpKeyword,
+	}
+	for _, k := range infixKeywords {
+		if p.expectToken(initialCursor, p.tokenFromKeyword(k)) {
+			return current, true
+		}
+	}
+
+	return nil, false
+}
+
+// parseExpression parses an expression until one of the delimiters,
+// operators with binding power lower than or equal to minBp are left to the caller.
+func (p *Parser) parseExpression(initialCursor uint, delimiters []Token, minBp uint) (*Expression, uint, bool, error) {
+	exp, cursor, ok, err := p.parsePrefixExpression(initialCursor, delimiters)
+	if !ok {
+		return nil, initialCursor, false, err
+	}
+
+	lastCursor := cursor
+outer:
+	for cursor < uint(len(p.tokens)) {
+		for _, delimiter := range delimiters {
+			if p.expectToken(cursor, delimiter) {
+				break outer
+			}
+		}
+
+		// the caller checks what follows the expression
+		op, ok := p.parseInfixOperator(cursor)
+		if !ok {
+			break
+		}
+
+		if op.bindingPower() <= minBp {
+			cursor = lastCursor
+			break
+		}
+
+		exp, cursor, ok, err = p.parseInfixExpression(exp, cursor, delimiters)
+		if !ok {
+			return nil, initialCursor, false, err
+		}
+		lastCursor = cursor
+	}
+
+	return exp, cursor, true, nil
+}
+
+func (p *Parser) parsePrefixExpression(initialCursor uint, delimiters []Token) (*Expression, uint, bool, error) {
+	cursor := initialCursor
+
+	if p.isSubquery(cursor) {
+		subquery, newCursor, ok, err := p.parseSubquery(cursor)
+		if !ok {
+			return nil, initialCursor, false, err
+		}
+
+		return &Expression{
+			Subquery: subquery,
+			Kind:     SubqueryKind,
+		}, newCursor, true, nil
+	}
+
+	if p.expectToken(cursor, p.tokenFromKeyword(ExistsKeyword)) {
+		cursor++
+
+		if !p.isSubquery(cursor) {
+			err := p.helpMessage(cursor, "Expected subquery after EXISTS")
+			return nil, initialCursor, false, err
+		}
+
+		subquery, newCursor, ok, err := p.parseSubquery(cursor)
+		if !ok {
+			return nil, initialCursor, false, err
+		}
+
+		return &Expression{
+			Subquery: subquery,
+			Kind:     ExistsKind,
+		}, newCursor, true, nil
+	}
+
+	if p.expectToken(cursor, p.tokenFromSymbol(leftParenSymbol)) {
+		cursor++
+
+		rightParen := p.tokenFromSymbol(rightParenSymbol)
+		innerDelimiters := append([]Token{rightParen}, delimiters...)
+
+		exp, newCursor, ok, err := p.parseExpression(cursor, innerDelimiters, 0)
+		if !ok {
+			return nil, initialCursor, false, err
+		}
+		cursor = newCursor
+
+		if !p.expectToken(cursor, rightParen) {
+			err := p.helpMessage(cursor, "Expected closing parenthesis")
+			return nil, initialCursor, false, err
+		}
+		cursor++
+
+		return exp, cursor, true, nil
+	}
+
+	if p.expectToken(cursor, p.tokenFromKeyword(NotKeyword)) {
+		op := p.tokens[cursor]
+		cursor++
+
+		operand, newCursor, ok, err := p.parseExpression(cursor, delimiters, notBindingPower)
+		if !ok {
+			return nil, initialCursor, false, err
+		}
+
+		return p.negate(operand, *op), newCursor, true, nil
+	}
+
+	isMinus := p.expectToken(cursor, Token{Kind: OperationKind, Value: string(MinusOperation)})
+	isPlus := p.expectToken(cursor, Token{Kind: OperationKind, Value: string(PlusOperation)})
+	if isMinus || isPlus {
+		op := p.tokens[cursor]
+		cursor++
+
+		operand, newCursor, ok, err := p.parseExpression(cursor, delimiters, unaryBindingPower)
+		if !ok {
+			return nil, initialCursor, false, err
+		}
+
+		return &Expression{
+			Unary: &UnaryExpression{
+				Operand: operand,
+				Op:      *op,
+			},
+			Kind: UnaryKind,
+		}, newCursor, true, nil
+	}
+
+	if p.expectToken(cursor, p.tokenFromKeyword(CaseKeyword)) {
+		return p.parseCase(cursor, delimiters)
+	}
+
+	if p.expectToken(cursor+1, p.tokenFromSymbol(leftParenSymbol)) {
+		if _, _, ok := p.parseToken(cursor, IdentifierKind); ok {
+			return p.parseFunctionCall(cursor)
+		}
+	}
+
+	if literal, ok := p.parseTypedLiteral(cursor); ok {
+		return &Expression{Literal: literal, Kind: LiteralKind}, cursor + 2, true, nil
+	}
+
+	exp, newCursor, ok := p.parseLiteralExpression(cursor)
+	if !ok {
+		err := p.helpMessage(cursor, "Expected expression")
+		return nil, initialCursor, false, err
+	}
+
+	return exp, newCursor, true, nil
+}
+
+// parseInfixExpression parses the operator at initialCursor and its right side,
+// 'NOT IN', 'NOT [I]LIKE', 'NOT REGEXP', 'NOT BETWEEN' and 'IS NOT NULL' are wrapped in a unary NOT.
+func (p *Parser) parseInfixExpression(left *Expression, initialCursor uint, delimiters []Token) (*Expression, uint, bool, error) {
+	cursor := initialCursor
+
+	var not *Token
+	if p.expectToken(cursor, p.tokenFromKeyword(NotKeyword)) {
+		not = p.tokens[cursor]
+		cursor++
+
+		negatable := []Keyword{InKeyword, LikeKeyword, IlikeKeyword, RegexpKeyword, BetweenKeyword}
+		found := false
+		for _, k := range negatable {
+			if p.expectToken(cursor, p.tokenFromKeyword(k)) {
+				found = true
+			}
+		}
+
+		if !found {
+			err := p.helpMessage(cursor, "Expected IN, LIKE, ILIKE, REGEXP or BETWEEN after NOT")
+			return nil, initialCursor, false, err
+		}
+	}
+
+	op := p.tokens[cursor]
+	cursor++
+
+	var exp *Expression
+	switch {
+	case p.expectToken(cursor-1, p.tokenFromKeyword(InKeyword)):
+		var list *Expression
+		if p.isSubquery(cursor) {
+			subquery, newCursor, ok, err := p.parseSubquery(cursor)
+			if !ok {
+				return nil, initialCursor, false, err
+			}
+			cursor = newCursor
+
+			list = &Expression{Subquery: subquery, Kind: SubqueryKind}
+		} else {
+			items, newCursor, ok, err := p.parseExpressionList(cursor)
+			if !ok {
+				return nil, initialCursor, false, err
+			}
+			cursor = newCursor
+
+			list = items
+		}
+
+		exp = &Expression{
+			Binary: &BinaryExpression{
+				A:  left,
+				B:  list,
+				Op: *op,
+			},
+			Kind: BinaryKind,
+		}
+	case p.expectToken(cursor-1, p.tokenFromKeyword(BetweenKeyword)):
+		and := p.tokenFromKeyword(AndKeyword)
+
+		low, newCursor, ok, err := p.parseExpression(cursor, delimiters, comparisonBindingPower)
+		if !ok {
+			return nil, initialCursor, false, err
+		}
+		cursor = newCursor
+
+		if !p.expectToken(cursor, and) {
+			err := p.helpMessage(cursor, "Expected AND in BETWEEN")
+			return nil, initialCursor, false, err
+		}
+		cursor++
+
+		high, newCursor, ok, err := p.parseExpression(cursor, delimiters, comparisonBindingPower)
+		if !ok {
+			return nil, initialCursor, false, err
+		}
+		cursor = newCursor
+
+		exp = &Expression{
+			Between: &BetweenExpression{
+				A:    left,
+				Low:  low,
+				High: high,
+			},
+			Kind: BetweenKind,
+		}
+	case p.expectToken(cursor-1, p.tokenFromKeyword(IsKeyword)):
+		if p.expectToken(cursor, p.tokenFromKeyword(NotKeyword)) {
+			not = p.tokens[cursor]
+			cursor++
+		}
+
+		if !p.expectToken(cursor, p.tokenFromKeyword(NullKeyword)) {
+			err := p.helpMessage(cursor, "Expected NULL after IS")
+			return nil, initialCursor, false, err
+		}
+		null := p.tokens[cursor]
+		cursor++
+
+		exp = &Expression{
+			Binary: &BinaryExpression{
+				A:  left,
+				B:  &Expression{Literal: null, Kind: LiteralKind},
+				Op: *op,
+			},
+			Kind: BinaryKind,
+		}
+	case p.expectToken(cursor-1, p.tokenFromKeyword(LikeKeyword)), p.expectToken(cursor-1, p.tokenFromKeyword(IlikeKeyword)):
+		like := &LikeExpression{A: left, Op: *op}
+
+		pattern, newCursor, ok, err := p.parseExpression(cursor, delimiters, comparisonBindingPower)
+		if !ok {
+			return nil, initialCursor, false, err
+		}
+		cursor = newCursor
+		like.Pattern = pattern
+
+		if p.expectToken(cursor, p.tokenFromKeyword(EscapeKeyword)) {
+			cursor++
+
+			escape, newCursor, ok, err := p.parseExpression(cursor, delimiters, comparisonBindingPower)
+			if !ok {
+				return nil, initialCursor, false, err
+			}
+			cursor = newCursor
+			like.Escape = escape
+		}
+
+		exp = &Expression{
+			Like: like,
+			Kind: LikeKind,
+		}
+	default:
+		b, newCursor, ok, err := p.parseExpression(cursor, delimiters, op.bindingPower())
+		if !ok {
+			return nil, initialCursor, false, err
+		}
+
+		// a pattern written in the query is checked here, patterns from the rows when they are read
+		if isRegexpOperator(op) && b.Kind == LiteralKind && b.Literal.Kind == StringKind {
+			if _, err := regexp.Compile(b.Literal.Value); err != nil {
+				return nil, initialCursor, false, fmt.Errorf("invalid regular expression '%s': %v", b.Literal.Value, err)
+			}
+		}
+		cursor = newCursor
+
+		exp = &Expression{
+			Binary: &BinaryExpression{
+				A:  left,
+				B:  b,
+				Op: *op,
+			},
+			Kind: BinaryKind,
+		}
+	}
+
+	if not != nil {
+		exp = p.negate(exp, *not)
+	}
+
+	return exp, cursor, true, nil
+}
+
+// parseCase parses 'CASE [exp] WHEN exp THEN exp ... [ELSE exp] END'.
+func (p *Parser) parseCase(initialCursor uint, delimiters []Token) (*Expression, uint, bool, error) {
+	cursor := initialCursor + 1
+
+	when := p.tokenFromKeyword(WhenKeyword)
+	then := p.tokenFromKeyword(ThenKeyword)
+
+	c := &CaseExpression{}
+	if !p.expectToken(cursor, when) {
+		operand, newCursor, ok, err := p.parseExpression(cursor, delimiters, 0)
+		if !ok {
+			return nil, initialCursor, false, err
+		}
+		cursor = newCursor
+		c.Operand = operand
+	}
+
+	for p.expectToken(cursor, when) {
+		cursor++
+
+		condition, newCursor, ok, err := p.parseExpression(cursor, delimiters, 0)
+		if !ok {
+			return nil, initialCursor, false, err
+		}
+		cursor = newCursor
+
+		if !p.expectToken(cursor, then) {
+			err := p.helpMessage(cursor, "Expected THEN")
+			return nil, initialCursor, false, err
+		}
+		cursor++
+
+		result, newCursor, ok, err := p.parseExpression(cursor, delimiters, 0)
+		if !ok {
+			return nil, initialCursor, false, err
+		}
+		cursor = newCursor
+
+		c.Whens = append(c.Whens, &WhenClause{When: condition, Result: result})
+	}
+
+	if len(c.Whens) == 0 {
+		err := p.helpMessage(cursor, "Expected WHEN")
+		return nil, initialCursor, false, err
+	}
+
+	if p.expectToken(cursor, p.tokenFromKeyword(ElseKeyword)) {
+		cursor++
+
+		result, newCursor, ok, err := p.parseExpression(cursor, delimiters, 0)
+		if !ok {
+			return nil, initialCursor, false, err
+		}
+		cursor = newCursor
+
+		c.Else = result
+	}
+
+	if !p.expectToken(cursor, p.tokenFromKeyword(EndKeyword)) {
+		err := p.helpMessage(cursor, "Expected END")
+		return nil, initialCursor, false, err
+	}
+	cursor++
+
+	return &Expression{
+		Case: c,
+		Kind: CaseKind,
+	}, cursor, true, nil
+}
+
+// parseTypedLiteral parses DATE '2016-06-01' and TIMESTAMP '2016-06-01 10:00:00', they are not keywords.
+func (p *Parser) parseTypedLiteral(cursor uint) (*Token, bool) {
+	if cursor+1 >= uint(len(p.tokens)) || p.tokens[cursor].Kind != IdentifierKind || p.tokens[cursor+1].Kind != StringKind {
+		return nil, false
+	}
+
+	literal := *p.tokens[cursor+1]
+	switch p.tokens[cursor].Value {
+	case "date":
+		literal.Kind = DateKind
+	case "timestamp":
+		literal.Kind = TimestampKind
+	default:
+		return nil, false
+	}
+
+	return &literal, true
+}
+
+// parseExtract parses 'field FROM source)' of EXTRACT, the field becomes the first argument as a string.
+func (p *Parser) parseExtract(initialCursor uint, function *FunctionCall) (uint, bool, error) {
+	cursor := initialCursor
+
+	field, newCursor, ok := p.parseToken(cursor, IdentifierKind)
+	if !ok {
+		err := p.helpMessage(cursor, "Expected field of EXTRACT")
+		return initialCursor, false, err
+	}
+	cursor = newCursor
+
+	if !p.expectToken(cursor, p.tokenFromKeyword(FromKeyword)) {
+		err := p.helpMessage(cursor, "Expected FROM in EXTRACT")
+		return initialCursor, false, err
+	}
+	cursor++
+
+	source, newCursor, ok, err := p.parseExpression(cursor, []Token{p.tokenFromSymbol(rightParenSymbol)}, 0)
+	if !ok {
+		return initialCursor, false, err
+	}
+	cursor = newCursor
+
+	fieldLiteral := &Token{Value: field.Value, Kind: StringKind, Loc: field.Loc}
+	function.Args = []*Expression{{Literal: fieldLiteral, Kind: LiteralKind}, source}
+
+	return cursor, true, nil
+}
+
+// parseFunctionCall parses 'name([DISTINCT] exp, ...)', COUNT(*) is stored with IsAllArgs.
+func (p *Parser) parseFunctionCall(initialCursor uint) (*Expression, uint, bool, error) {
+	cursor := initialCursor
+
+	function := &FunctionCall{Name: *p.tokens[cursor]}
+	cursor += 2
+
+	rightParen := p.tokenFromSymbol(rightParenSymbol)
+	comma := p.tokenFromSymbol(commaSymbol)
+
+	if p.expectToken(cursor, p.tokenFromKeyword(DistinctKeyword)) {
+		function.Distinct = true
+		cursor++
+	}
+
+	switch {
+	case p.expectToken(cursor, p.tokenFromSymbol(allFields)):
+		function.IsAllArgs = true
+		cursor++
+	case function.Name.Value == "extract" && p.expectToken(cursor+1, p.tokenFromKeyword(FromKeyword)):
+		newCursor, ok, err := p.parseExtract(cursor, function)
+		if !ok {
+			return nil, initialCursor, false, err
+		}
+		cursor = newCursor
+	case !p.expectToken(cursor, rightParen):
+		for {
+			arg, newCursor, ok, err := p.parseExpression(cursor, []Token{comma, rightParen}, 0)
+			if !ok {
+				return nil, initialCursor, false, err
+			}
+			cursor = newCursor
+
+			function.Args = append(function.Args, arg)
+
+			if !p.expectToken(cursor, comma) {
+				break
+			}
+			cursor++
+		}
+	}
+
+	if !p.expectToken(cursor, rightParen) {
+		err := p.helpMessage(cursor, "Expected closing parenthesis")
+		return nil, initialCursor, false, err
+	}
+	cursor++
+
+	if p.expectToken(cursor, p.tokenFromKeyword(OverKeyword)) {
+		window, newCursor, ok, err := p.parseWindow(cursor + 1)
+		if !ok {
+			return nil, initialCursor, false, err
+		}
+
+		function.Over = window
+		cursor = newCursor
+	}
+
+	return &Expression{
+		Function: function,
+		Kind:     FunctionKind,
+	}, cursor, true, nil
+}
+
+// parseWindow parses '(PARTITION BY exp, ... ORDER BY ... frame)' after OVER.
+func (p *Parser) parseWindow(initialCursor uint) (*Window, uint, bool, error) {
+	cursor := initialCursor
+
+	if !p.expectToken(cursor, p.tokenFromSymbol(leftParenSymbol)) {
+		err := p.helpMessage(cursor, "Expected opening parenthesis after OVER")
+		return nil, initialCursor, false, err
+	}
+	cursor++
+
+	rightParen := p.tokenFromSymbol(rightParenSymbol)
+	frameStart := []Token{p.tokenFromKeyword(RowsKeyword), p.tokenFromKeyword(RangeKeyword), rightParen}
+
+	window := &Window{}
+	if p.expectToken(cursor, p.tokenFromKeyword(PartitionKeyword)) {
+		cursor++
+
+		if !p.expectToken(cursor, p.tokenFromKeyword(ByKeyword)) {
+			err := p.helpMessage(cursor, "Expected BY after PARTITION")
+			return nil, initialCursor, false, err
+		}
+		cursor++
+
+		partitionEnd := append([]Token{p.tokenFromKeyword(OrderKeyword)}, frameStart...)
+
+		partitionBy, newCursor, ok, _, err := p.parseExpressions(cursor, partitionEnd, false)
+		if !ok {
+			return nil, initialCursor, false, err
+		}
+
+		window.PartitionBy = *partitionBy
+		cursor = newCursor
+	}
+
+	if p.expectToken(cursor, p.tokenFromKeyword(OrderKeyword)) {
+		cursor++
+
+		orderBy, newCursor, ok, err := p.parseOrderBy(cursor, frameStart)
+		if !ok {
+			return nil, initialCursor, false, err
+		}
+
+		window.OrderBy = orderBy
+		cursor = newCursor
+	}
+
+	isRows := p.expectToken(cursor, p.tokenFromKeyword(RowsKeyword))
+	if isRows || p.expectToken(cursor, p.tokenFromKeyword(RangeKeyword)) {
+		frame, newCursor, ok, err := p.parseFrame(cursor+1, !isRows)
+		if !ok {
+			return nil, initialCursor, false, err
+		}
+
+		window.Frame = frame
+		cursor = newCursor
+	}
+
+	if !p.expectToken(cursor, rightParen) {
+		err := p.helpMessage(cursor, "Expected closing parenthesis")
+		return nil, initialCursor, false, err
+	}
+	cursor++
+
+	return window, cursor, true, nil
+}
+
+// parseFrame parses 'BETWEEN bound AND bound' or 'bound' after ROWS or RANGE.
+func (p *Parser) parseFrame(initialCursor uint, isRange bool) (*Frame, uint, bool, error) {
+	cursor := initialCursor
+
+	frame := &Frame{IsRange: isRange, End: FrameBound{Kind: CurrentRow}}
+
+	isBetween := p.expectToken(cursor, p.tokenFromKeyword(BetweenKeyword))
+	if isBetween {
+		cursor++
+	}
+
+	start, newCursor, ok, err := p.parseFrameBound(cursor)
+	if !ok {
+		return nil, initialCursor, false, err
+	}
+	frame.Start = start
+	cursor = newCursor
+
+	if isBetween {
+		if !p.expectToken(cursor, p.tokenFromKeyword(AndKeyword)) {
+			err := p.helpMessage(cursor, "Expected AND in BETWEEN")
+			return nil, initialCursor, false, err
+		}
+		cursor++
+
+		end, newCursor, ok, err := p.parseFrameBound(cursor)
+		if !ok {
+			return nil, initialCursor, false, err
+		}
+		frame.End = end
+		cursor = newCursor
+	}
+
+	switch {
+	case frame.Start.Kind == UnboundedFollowing:
+		return nil, initialCursor, false, fmt.Errorf("frame start cannot be UNBOUNDED FOLLOWING")
+	case frame.End.Kind == UnboundedPreceding:
+		return nil, initialCursor, false, fmt.Errorf("frame end cannot be UNBOUNDED PRECEDING")
+	case frame.End.Kind < frame.Start.Kind:
+		return nil, initialCursor, false, fmt.Errorf("frame starting from %s cannot end with %s", frame.Start, frame.End)
+	}
+
+	return frame, cursor, true, nil
+}
+
+func (p *Parser) parseFrameBound(initialCursor uint) (FrameBound, uint, bool, error) {
+	cursor := initialCursor
+
+	preceding := p.tokenFromKeyword(PrecedingKeyword)
+	following := p.tokenFromKeyword(FollowingKeyword)
+
+	switch {
+	case p.expectToken(cursor, p.tokenFromKeyword(UnboundedKeyword)):
+		cursor++
+
+		switch {
+		case p.expectToken(cursor, preceding):
+			return FrameBound{Kind: UnboundedPreceding}, cursor + 1, true, nil
+		case p.expectToken(cursor, following):
+			return FrameBound{Kind: UnboundedFollowing}, cursor + 1, true, nil
+		}
+
+		err := p.helpMessage(cursor, "Expected PRECEDING or FOLLOWING")
+		return FrameBound{}, initialCursor, false, err
+	case p.expectToken(cursor, p.tokenFromKeyword(CurrentKeyword)):
+		cursor++
+
+		if !p.expectToken(cursor, p.tokenFromKeyword(RowKeyword)) {
+			err := p.helpMessage(cursor, "Expected ROW after CURRENT")
+			return FrameBound{}, initialCursor, false, err
+		}
+
+		return FrameBound{Kind: CurrentRow}, cursor + 1, true, nil
+	}
+
+	offset, newCursor, ok, err := p.parseCount(cursor, "Expected UNBOUNDED, CURRENT ROW or number in frame")
+	if !ok {
+		return FrameBound{}, initialCursor, false, err
+	}
+	cursor = newCursor
+
+	switch {
+	case p.expectToken(cursor, preceding):
+		return FrameBound{Kind: Preceding, Offset: offset}, cursor + 1, true, nil
+	case p.expectToken(cursor, following):
+		return FrameBound{Kind: Following, Offset: offset}, cursor + 1, true, nil
+	}
+
+	err = p.helpMessage(cursor, "Expected PRECEDING or FOLLOWING")
+	return FrameBound{}, initialCursor, false, err
+}
+
+// parseExpressionList parses '(exp, exp, ...)' used by IN.
+func (p *Parser) parseExpressionList(initialCursor uint) (*Expression, uint, bool, error) {
+	cursor := initialCursor
+
+	if !p.expectToken(cursor, p.tokenFromSymbol(leftParenSymbol)) {
+		err := p.helpMessage(cursor, "Expected opening parenthesis")
+		return nil, initialCursor, false, err
+	}
+	cursor++
+
+	comma := p.tokenFromSymbol(commaSymbol)
+	rightParen := p.tokenFromSymbol(rightParenSymbol)
+
+	list := []*Expression{}
+	for {
+		item, newCursor, ok, err := p.parseExpression(cursor, []Token{comma, rightParen}, 0)
+		if !ok {
+			return nil, initialCursor, false, err
+		}
+		cursor = newCursor
+
+		list = append(list, item)
+
+		if p.expectToken(cursor, rightParen) {
+			cursor++
+			break
+		}
+
+		if !p.expectToken(cursor, comma) {
+			err := p.helpMessage(cursor, "Expected comma")
+			return nil, initialCursor, false, err
+		}
+		cursor++
+	}
+
+	return &Expression{
+		List: list,
+		Kind: ListKind,
+	}, cursor, true, nil
+}
+
+func isRegexpOperator(op *Token) bool {
+	if op.Kind == KeywordKind {
+		return Keyword(op.Value) == RegexpKeyword
+	}
+
+	switch Operation(op.Value) {
+	case RegexpOperation, RegexpInsensitiveOperation, NotRegexpOperation, NotRegexpInsensitiveOperation:
+		return op.Kind == OperationKind
+	}
+
+	return false
+}
+
+func (p *Parser) negate(exp *Expression, not Token) *Expression {
+	return &Expression{
+		Unary: &UnaryExpression{
+			Operand: exp,
+			Op:      not,
+		},
+		Kind: UnaryKind,
+	}
+}
@@ -18,15 +18,22 @@ func lex(source string) ([]*Token, error) {
 
 lex:
 	for cur.pointer < uint(len(source)) {
-		lexers := []lexer{lexKeyword, lexSymbol, lexString, lexNumeric, lexIdentifier, lexOperation}
+		rest := source[cur.pointer:]
+		if strings.HasPrefix(rest, "/*") && !strings.Contains(rest[2:], "*/") {
+			return nil, fmt.Errorf("unterminated comment, at %d:%d", cur.loc.Line, cur.loc.Col)
+		}
+
+		// comments go first, so '--' and '/*' are not lexed as operations
+		lexers := []lexer{lexComment, lexKeyword, lexSymbol, lexString, lexNumeric, lexIdentifier, lexOperation}
 		for _, l := range lexers {
 			if token, newCursor, ok := l(source, cur); ok {
-				cur = newCursor
-
 				if token != nil {
+					token.Loc.Offset = cur.pointer
 					tokens = append(tokens, token)
 				}
 
+				cur = newCursor
+
 				continue lex
 			}
 		}
@@ -41,6 +48,49 @@ lex:
 	return tokens, nil
 }
 
+// lexComment lexes '-- comment' up to the end of the line and '/* comment */',
+// the value is the text of the comment.
+func lexComment(source string, ic cursor) (*Token, cursor, bool) {
+	cur := ic
+	rest := source[cur.pointer:]
+
+	var text string
+	switch {
+	case strings.HasPrefix(rest, "--"):
+		text = rest[2:]
+		if end := strings.IndexByte(text, '\n'); end != -1 {
+			text = text[:end]
+		}
+
+		cur.pointer += uint(len(text)) + 2
+		cur.loc.Col += uint(len(text)) + 2
+	case strings.HasPrefix(rest, "/*"):
+		end := strings.Index(rest[2:], "*/")
+		if end == -1 {
+			return nil, ic, false
+		}
+		text = rest[2 : end+2]
+
+		for _, c := range []byte(rest[:end+4]) {
+			cur.pointer++
+			cur.loc.Col++
+
+			if c == '\n' {
+				cur.loc.Line++
+				cur.loc.Col = 0
+			}
+		}
+	default:
+		return nil, ic, false
+	}
+
+	return &Token{
+		Value: strings.TrimSpace(text),
+		Loc:   ic.loc,
+		Kind:  CommentKind,
+	}, cur, true
+}
+
 func lexOperation(source string, ic cursor) (*Token, cursor, bool) {
 	c := source[ic.pointer]
 	cur := ic
@@ -67,6 +117,16 @@ func lexOperation(source string, ic cursor) (*Token, cursor, bool) {
 		LessOperation,
 		MoreEqualOperation,
 		LessEqualOperation,
+		NotEqualAliasOperation,
+		PlusOperation,
+		MinusOperation,
+		DivideOperation,
+		ModuloOperation,
+		ConcatOperation,
+		RegexpOperation,
+		RegexpInsensitiveOperation,
+		NotRegexpOperation,
+		NotRegexpInsensitiveOperation,
 	}
 
 	options := make([]string, 0, len(operations))
@@ -82,6 +142,10 @@ func lexOperation(source string, ic cursor) (*Token, cursor, bool) {
 	cur.pointer = ic.pointer + uint(len(match))
 	cur.loc.Col = ic.loc.Col + uint(len(match))
 
+	if Operation(match) == NotEqualAliasOperation {
+		match = string(NotEqualOperation)
+	}
+
 	return &Token{
 		Value: match,
 		Loc:   ic.loc,
@@ -145,6 +209,8 @@ func lexNumeric(source string, ic cursor) (*Token, cursor, bool) {
 		}
 
 		if !isDigit {
+			// the character after the number is not a part of it
+			cur.loc.Col--
 			break
 		}
 	}
@@ -196,6 +262,11 @@ func lexCharacterDelimited(source string, ic cursor, delimiter byte) (*Token, cu
 
 		value = append(value, c)
 		cur.loc.Col++
+
+		if c == '\n' {
+			cur.loc.Line++
+			cur.loc.Col = 0
+		}
 	}
 
 	return nil, ic, false
@@ -227,6 +298,8 @@ func lexSymbol(source string, ic cursor) (*Token, cursor, bool) {
 		commaSymbol,
 		semicolonSymbol,
 		allFields,
+		leftParenSymbol,
+		rightParenSymbol,
 	}
 
 	options := make([]string, 0, len(symbols))
@@ -258,6 +331,60 @@ func lexKeyword(source string, ic cursor) (*Token, cursor, bool) {
 		FromKeyword,
 		AndKeyword,
 		OrKeyword,
+		NotKeyword,
+		InKeyword,
+		LikeKeyword,
+		BetweenKeyword,
+		OrderKeyword,
+		ByKeyword,
+		AscKeyword,
+		DescKeyword,
+		NullsKeyword,
+		FirstKeyword,
+		LastKeyword,
+		LimitKeyword,
+		OffsetKeyword,
+		FetchKeyword,
+		NextKeyword,
+		RowKeyword,
+		RowsKeyword,
+		OnlyKeyword,
+		GroupKeyword,
+		DistinctKeyword,
+		HavingKeyword,
+		OnKeyword,
+		AsKeyword,
+		IlikeKeyword,
+		EscapeKeyword,
+		IsKeyword,
+		NullKeyword,
+		RegexpKeyword,
+		CaseKeyword,
+		WhenKeyword,
+		ThenKeyword,
+		ElseKeyword,
+		EndKeyword,
+		JoinKeyword,
+		InnerKeyword,
+		LeftKeyword,
+		RightKeyword,
+		FullKeyword,
+		OuterKeyword,
+		CrossKeyword,
+		ExistsKeyword,
+		UnionKeyword,
+		IntersectKeyword,
+		ExceptKeyword,
+		AllKeyword,
+		WithKeyword,
+		RecursiveKeyword,
+		OverKeyword,
+		PartitionKeyword,
+		RangeKeyword,
+		UnboundedKeyword,
+		PrecedingKeyword,
+		FollowingKeyword,
+		CurrentKeyword,
 	}
 
 	options := make([]string, 0, len(keywords))
@@ -270,6 +397,12 @@ func lexKeyword(source string, ic cursor) (*Token, cursor, bool) {
 		return nil, ic, false
 	}
 
+	// keyword is only a prefix of an identifier like 'notes' or 'index', or a table name like 'left.x'
+	end := ic.pointer + uint(len(match))
+	if end < uint(len(source)) && (isIdentifierChar(source[end]) || source[end] == '.') {
+		return nil, ic, false
+	}
+
 	cur.pointer = ic.pointer + uint(len(match))
 	cur.loc.Col = ic.loc.Col + uint(len(match))
 
@@ -324,7 +457,10 @@ func longestMatch(source string, ic cursor, options []string) string {
 }
 
 func lexIdentifier(source string, ic cursor) (*Token, cursor, bool) {
+	// quoted identifier, used for column names like "Group" which are keywords
 	if token, newCursor, ok := lexCharacterDelimited(source, ic, '"'); ok {
+		token.Value = strings.ToLower(token.Value)
+		token.Kind = IdentifierKind
 		return token, newCursor, true
 	}
 
@@ -342,9 +478,16 @@ func lexIdentifier(source string, ic cursor) (*Token, cursor, bool) {
 	for ; cur.pointer < uint(len(source)); cur.pointer++ {
 		c = source[cur.pointer]
 
-		isAlphabetical := (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
-		isNumeric := c >= '0' && c <= '9'
-		if isAlphabetical || isNumeric || c == '$' || c == '_' || c == '/' {
+		if isIdentifierChar(c) {
+			value = append(value, c)
+			cur.loc.Col++
+			continue
+		}
+
+		// column qualified with a table name or alias, like 'b.status'
+		next := cur.pointer + 1
+		if c == '.' && next < uint(len(source)) &&
+			((source[next] >= 'A' && source[next] <= 'Z') || (source[next] >= 'a' && source[next] <= 'z')) {
 			value = append(value, c)
 			cur.loc.Col++
 			continue
@@ -363,3 +506,10 @@ func lexIdentifier(source string, ic cursor) (*Token, cursor, bool) {
 		Kind:  IdentifierKind,
 	}, cur, true
 }
+
+func isIdentifierChar(c byte) bool {
+	isAlphabetical := (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
+	isNumeric := c >= '0' && c <= '9'
+
+	return isAlphabetical || isNumeric || c == '$' || c == '_'
+}
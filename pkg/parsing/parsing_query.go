@@ -2,11 +2,15 @@ package parsing
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 )
 
 type Location struct {
 	Line uint
 	Col  uint
+	// Offset is the position of the token in the source
+	Offset uint
 }
 
 type Token struct {
@@ -15,29 +19,6 @@ type Token struct {
 	Loc   Location
 }
 
-type SelectStatement struct {
-	Item       []*Expression
-	From       Token
-	Where      []interface{}
-	IsAllItems bool
-}
-
-type Expression struct {
-	Literal *Token
-}
-
-//for where
-type Conditions struct {
-	Literal   Token
-	Operation Token
-	Value     Token
-}
-
-//for where
-type Predicate struct {
-	Predicate Token
-}
-
 func (t *Token) equals(other *Token) bool {
 	return t.Value == other.Value && t.Kind == other.Kind
 }
@@ -68,18 +49,263 @@ func (p *Parser) helpMessage(cursor uint, msg string) error {
 	return fmt.Errorf("%s, got: %s", msg, c.Value)
 }
 
-func (p *Parser) parseSelectStatement(initialCursor uint, delimiter Token) (*SelectStatement, bool, error) {
+// parseSelectStatement parses a statement which ends with the delimiter, ';' or ')' of a subquery,
+// the returned cursor points to the delimiter.
+func (p *Parser) parseSelectStatement(initialCursor uint, delimiter Token) (*SelectStatement, uint, bool, error) {
+	// limitEnd holds tokens which can follow ORDER BY
+	limitEnd := []Token{
+		p.tokenFromKeyword(LimitKeyword),
+		p.tokenFromKeyword(OffsetKeyword),
+		p.tokenFromKeyword(FetchKeyword),
+		delimiter,
+	}
+
+	cursor := initialCursor
+
+	var with *With
+	if p.expectToken(cursor, p.tokenFromKeyword(WithKeyword)) {
+		w, newCursor, ok, err := p.parseWith(cursor)
+		if !ok {
+			return nil, initialCursor, false, err
+		}
+
+		with = w
+		cursor = newCursor
+	}
+
+	slct, cursor, ok, err := p.parseCompound(cursor, append([]Token{p.tokenFromKeyword(OrderKeyword)}, limitEnd...))
+	if !ok {
+		return nil, initialCursor, false, err
+	}
+	slct.With = with
+
+	if p.expectToken(cursor, p.tokenFromKeyword(OrderKeyword)) {
+		cursor++
+
+		orderBy, newCursor, ok, err := p.parseOrderBy(cursor, limitEnd)
+		if !ok {
+			return nil, initialCursor, false, err
+		}
+
+		slct.OrderBy = orderBy
+		cursor = newCursor
+	}
+
+	newCursor, ok, err := p.parseLimit(cursor, slct)
+	if !ok {
+		return nil, initialCursor, false, err
+	}
+	cursor = newCursor
+
+	if !p.expectToken(cursor, delimiter) {
+		err := p.helpMessage(cursor, fmt.Sprintf("Expected '%s'", delimiter.Value))
+		return nil, initialCursor, false, err
+	}
+
+	return slct, cursor, true, nil
+}
+
+// parseWith parses 'WITH [RECURSIVE] name [(column, ...)] AS (SELECT ...), ...'.
+func (p *Parser) parseWith(initialCursor uint) (*With, uint, bool, error) {
+	cursor := initialCursor + 1
+
+	with := &With{}
+	if p.expectToken(cursor, p.tokenFromKeyword(RecursiveKeyword)) {
+		with.Recursive = true
+		cursor++
+	}
+
+	leftParen := p.tokenFromSymbol(leftParenSymbol)
+	rightParen := p.tokenFromSymbol(rightParenSymbol)
+	comma := p.tokenFromSymbol(commaSymbol)
+
+	for {
+		name, newCursor, ok := p.parseToken(cursor, IdentifierKind)
+		if !ok {
+			err := p.helpMessage(cursor, "Expected name of WITH query")
+			return nil, initialCursor, false, err
+		}
+		cursor = newCursor
+
+		table := &CommonTable{Name: name.Value}
+
+		if p.expectToken(cursor, leftParen) {
+			cursor++
+
+			for {
+				column, newCursor, ok := p.parseToken(cursor, IdentifierKind)
+				if !ok {
+					err := p.helpMessage(cursor, "Expected column name")
+					return nil, initialCursor, false, err
+				}
+				cursor = newCursor
+
+				table.Columns = append(table.Columns, column.Value)
+
+				if !p.expectToken(cursor, comma) {
+					break
+				}
+				cursor++
+			}
+
+			if !p.expectToken(cursor, rightParen) {
+				err := p.helpMessage(cursor, "Expected closing parenthesis")
+				return nil, initialCursor, false, err
+			}
+			cursor++
+		}
+
+		if !p.expectToken(cursor, p.tokenFromKeyword(AsKeyword)) {
+			err := p.helpMessage(cursor, "Expected AS")
+			return nil, initialCursor, false, err
+		}
+		cursor++
+
+		if !p.isSubquery(cursor) {
+			err := p.helpMessage(cursor, "Expected subquery after AS")
+			return nil, initialCursor, false, err
+		}
+
+		subquery, newCursor, ok, err := p.parseSubquery(cursor)
+		if !ok {
+			return nil, initialCursor, false, err
+		}
+		cursor = newCursor
+
+		table.Select = subquery
+		with.Tables = append(with.Tables, table)
+
+		if !p.expectToken(cursor, comma) {
+			break
+		}
+		cursor++
+	}
+
+	return with, cursor, true, nil
+}
+
+// parseCompound parses selects joined with UNION, INTERSECT and EXCEPT, ORDER BY and the limit
+// after the last one are left to the caller, they are applied to the combined rows.
+// Selects after the first one can be written in parentheses with their own ORDER BY and limit.
+func (p *Parser) parseCompound(initialCursor uint, delimiters []Token) (*SelectStatement, uint, bool, error) {
+	kinds := map[Keyword]SetOperationKind{
+		UnionKeyword:     Union,
+		IntersectKeyword: Intersect,
+		ExceptKeyword:    Except,
+	}
+
+	setEnd := append([]Token{
+		p.tokenFromKeyword(UnionKeyword),
+		p.tokenFromKeyword(IntersectKeyword),
+		p.tokenFromKeyword(ExceptKeyword),
+	}, delimiters...)
+
+	first, cursor, ok, err := p.parseSelectCore(initialCursor, setEnd)
+	if !ok {
+		return nil, initialCursor, false, err
+	}
+
+	operands := []*SelectStatement{first}
+	var ops []*Compound
+	for {
+		op := &Compound{}
+
+		found := false
+		for keyword, kind := range kinds {
+			if p.expectToken(cursor, p.tokenFromKeyword(keyword)) {
+				op.Kind = kind
+				found = true
+			}
+		}
+
+		if !found {
+			break
+		}
+		cursor++
+
+		if p.expectToken(cursor, p.tokenFromKeyword(AllKeyword)) {
+			op.All = true
+			cursor++
+		}
+
+		var operand *SelectStatement
+		var newCursor uint
+		if p.isSubquery(cursor) {
+			operand, newCursor, ok, err = p.parseSubquery(cursor)
+		} else {
+			operand, newCursor, ok, err = p.parseSelectCore(cursor, setEnd)
+		}
+		if !ok {
+			return nil, initialCursor, false, err
+		}
+		cursor = newCursor
+
+		ops = append(ops, op)
+		operands = append(operands, operand)
+	}
+
+	// INTERSECT is applied before UNION and EXCEPT, as in postgres
+	merged := []*SelectStatement{operands[0]}
+	var rest []*Compound
+	for idx, op := range ops {
+		if op.Kind == Intersect {
+			op.Left, op.Right = merged[len(merged)-1], operands[idx+1]
+			merged[len(merged)-1] = &SelectStatement{Compound: op}
+			continue
+		}
+
+		merged = append(merged, operands[idx+1])
+		rest = append(rest, op)
+	}
+
+	slct := merged[0]
+	for idx, op := range rest {
+		op.Left, op.Right = slct, merged[idx+1]
+		slct = &SelectStatement{Compound: op}
+	}
+
+	return slct, cursor, true, nil
+}
+
+// parseSelectCore parses a select without ORDER BY and the limit until one of the delimiters.
+func (p *Parser) parseSelectCore(initialCursor uint, delimiters []Token) (*SelectStatement, uint, bool, error) {
 	cursor := initialCursor
 	if !p.expectToken(cursor, p.tokenFromKeyword(SelectKeyword)) {
-		return nil, false, fmt.Errorf("Expected SELECT statement")
+		return nil, initialCursor, false, fmt.Errorf("Expected SELECT statement")
 	}
 	cursor++
 
 	slct := SelectStatement{}
 
-	exps, newCursor, ok, isAllItems, err := p.parseExpressions(cursor, []Token{p.tokenFromKeyword(FromKeyword), delimiter})
+	if p.expectToken(cursor, p.tokenFromKeyword(DistinctKeyword)) {
+		cursor++
+
+		if p.expectToken(cursor, p.tokenFromKeyword(OnKeyword)) {
+			cursor++
+
+			list, newCursor, ok, err := p.parseExpressionList(cursor)
+			if !ok {
+				return nil, initialCursor, false, err
+			}
+
+			slct.DistinctOn = list.List
+			cursor = newCursor
+		} else {
+			slct.Distinct = true
+		}
+	}
+
+	// clauseEnd holds tokens which can follow WHERE
+	clauseEnd := append([]Token{
+		p.tokenFromKeyword(GroupKeyword),
+		p.tokenFromKeyword(HavingKeyword),
+	}, delimiters...)
+
+	itemsEnd := append([]Token{p.tokenFromKeyword(FromKeyword), p.tokenFromKeyword(WhereKeyword)}, clauseEnd...)
+
+	exps, newCursor, ok, isAllItems, err := p.parseExpressions(cursor, itemsEnd, true)
 	if !ok {
-		return nil, false, err
+		return nil, initialCursor, false, err
 	}
 
 	if isAllItems {
@@ -93,30 +319,341 @@ func (p *Parser) parseSelectStatement(initialCursor uint, delimiter Token) (*Sel
 	if p.expectToken(cursor, p.tokenFromKeyword(FromKeyword)) {
 		cursor++
 
-		from, newCursor, ok := p.parseToken(cursor, IdentifierKind)
+		from, subquery, alias, newCursor, ok, err := p.parseTable(cursor, "Expected FROM token")
 		if !ok {
-			err := p.helpMessage(cursor, "Expected FROM token")
-			return nil, false, err
+			return nil, initialCursor, false, err
+		}
+
+		slct.From = from
+		slct.FromSubquery = subquery
+		slct.FromAlias = alias
+		cursor = newCursor
+
+		joinEnd := append([]Token{p.tokenFromKeyword(WhereKeyword)}, clauseEnd...)
+
+		joins, newCursor, ok, err := p.parseJoins(cursor, joinEnd)
+		if !ok {
+			return nil, initialCursor, false, err
+		}
+
+		slct.Joins = joins
+		cursor = newCursor
+	}
+
+	if p.expectToken(cursor, p.tokenFromKeyword(WhereKeyword)) {
+		cursor++
+
+		where, newCursor, ok, err := p.parseExpression(cursor, clauseEnd, 0)
+		if !ok {
+			return nil, initialCursor, false, err
+		}
+
+		slct.Where = where
+		cursor = newCursor
+	}
+
+	if p.expectToken(cursor, p.tokenFromKeyword(GroupKeyword)) {
+		cursor++
+
+		if !p.expectToken(cursor, p.tokenFromKeyword(ByKeyword)) {
+			err := p.helpMessage(cursor, "Expected BY after GROUP")
+			return nil, initialCursor, false, err
+		}
+		cursor++
+
+		groupBy, newCursor, ok, _, err := p.parseExpressions(cursor, clauseEnd, false)
+		if !ok {
+			return nil, initialCursor, false, err
+		}
+
+		if len(*groupBy) == 0 {
+			err := p.helpMessage(cursor, "Expected expression after GROUP BY")
+			return nil, initialCursor, false, err
+		}
+
+		slct.GroupBy = *groupBy
+		cursor = newCursor
+	}
+
+	if p.expectToken(cursor, p.tokenFromKeyword(HavingKeyword)) {
+		cursor++
+
+		having, newCursor, ok, err := p.parseExpression(cursor, clauseEnd, 0)
+		if !ok {
+			return nil, initialCursor, false, err
+		}
+
+		slct.Having = having
+		cursor = newCursor
+	}
+
+	return &slct, cursor, true, nil
+}
+
+// parseTable parses a table of FROM or JOIN with an optional alias, or a subquery
+// '(SELECT ...) alias' which must have the alias.
+func (p *Parser) parseTable(initialCursor uint, msg string) (Token, *SelectStatement, string, uint, bool, error) {
+	cursor := initialCursor
+
+	var table Token
+	var subquery *SelectStatement
+	if p.expectToken(cursor, p.tokenFromSymbol(leftParenSymbol)) {
+		sub, newCursor, ok, err := p.parseSubquery(cursor)
+		if !ok {
+			return table, nil, "", initialCursor, false, err
+		}
+
+		subquery = sub
+		cursor = newCursor
+	} else {
+		t, newCursor, ok := p.parseToken(cursor, IdentifierKind)
+		if !ok {
+			return table, nil, "", initialCursor, false, p.helpMessage(cursor, msg)
+		}
+
+		table = *t
+		cursor = newCursor
+	}
+
+	alias, newCursor, ok, err := p.parseAlias(cursor)
+	if !ok {
+		return table, nil, "", initialCursor, false, err
+	}
+
+	if subquery != nil && alias == "" {
+		return table, nil, "", initialCursor, false, p.helpMessage(cursor, "Expected alias for subquery in FROM")
+	}
+
+	return table, subquery, alias, newCursor, true, nil
+}
+
+// parseSubquery parses '(SELECT ...)'.
+func (p *Parser) parseSubquery(initialCursor uint) (*SelectStatement, uint, bool, error) {
+	cursor := initialCursor + 1
+
+	rightParen := p.tokenFromSymbol(rightParenSymbol)
+
+	subquery, newCursor, ok, err := p.parseSelectStatement(cursor, rightParen)
+	if !ok {
+		return nil, initialCursor, false, err
+	}
+
+	return subquery, newCursor + 1, true, nil
+}
+
+// isSubquery reports whether '(SELECT' or '(WITH' starts at the cursor.
+func (p *Parser) isSubquery(cursor uint) bool {
+	if !p.expectToken(cursor, p.tokenFromSymbol(leftParenSymbol)) {
+		return false
+	}
+
+	return p.expectToken(cursor+1, p.tokenFromKeyword(SelectKeyword)) || p.expectToken(cursor+1, p.tokenFromKeyword(WithKeyword))
+}
+
+// parseJoins parses joins following the first table of FROM.
+func (p *Parser) parseJoins(initialCursor uint, delimiters []Token) ([]*Join, uint, bool, error) {
+	cursor := initialCursor
+
+	kinds := map[Keyword]JoinKind{
+		InnerKeyword: InnerJoin,
+		LeftKeyword:  LeftJoin,
+		RightKeyword: RightJoin,
+		FullKeyword:  FullJoin,
+		CrossKeyword: CrossJoin,
+	}
+
+	var joins []*Join
+	for {
+		join := &Join{Kind: InnerJoin}
+
+		for keyword, kind := range kinds {
+			if p.expectToken(cursor, p.tokenFromKeyword(keyword)) {
+				join.Kind = kind
+				cursor++
+
+				isOuter := kind == LeftJoin || kind == RightJoin || kind == FullJoin
+				if isOuter && p.expectToken(cursor, p.tokenFromKeyword(OuterKeyword)) {
+					cursor++
+				}
+
+				if !p.expectToken(cursor, p.tokenFromKeyword(JoinKeyword)) {
+					err := p.helpMessage(cursor, "Expected JOIN")
+					return nil, initialCursor, false, err
+				}
+				break
+			}
+		}
+
+		if !p.expectToken(cursor, p.tokenFromKeyword(JoinKeyword)) {
+			return joins, cursor, true, nil
 		}
+		cursor++
 
-		slct.From = *from
+		table, subquery, alias, newCursor, ok, err := p.parseTable(cursor, "Expected table after JOIN")
+		if !ok {
+			return nil, initialCursor, false, err
+		}
+		join.Table = table
+		join.Subquery = subquery
+		join.Alias = alias
 		cursor = newCursor
+
+		if join.Kind != CrossJoin {
+			if !p.expectToken(cursor, p.tokenFromKeyword(OnKeyword)) {
+				err := p.helpMessage(cursor, "Expected ON")
+				return nil, initialCursor, false, err
+			}
+			cursor++
+
+			on, newCursor, ok, err := p.parseExpression(cursor, delimiters, 0)
+			if !ok {
+				return nil, initialCursor, false, err
+			}
+			join.On = on
+			cursor = newCursor
+		}
+
+		joins = append(joins, join)
 	}
+}
 
-	//for where
-	if !p.expectToken(cursor, p.tokenFromKeyword(WhereKeyword)) {
-		return nil, false, fmt.Errorf("expected WHERE")
+func (p *Parser) parseOrderBy(initialCursor uint, delimiters []Token) ([]*OrderItem, uint, bool, error) {
+	cursor := initialCursor
+
+	if !p.expectToken(cursor, p.tokenFromKeyword(ByKeyword)) {
+		err := p.helpMessage(cursor, "Expected BY after ORDER")
+		return nil, initialCursor, false, err
 	}
 	cursor++
 
-	where, ok := p.parseWhere(cursor, p.tokenFromSymbol(semicolonSymbol))
+	itemEnd := append([]Token{
+		p.tokenFromSymbol(commaSymbol),
+		p.tokenFromKeyword(AscKeyword),
+		p.tokenFromKeyword(DescKeyword),
+		p.tokenFromKeyword(NullsKeyword),
+	}, delimiters...)
+
+	items := []*OrderItem{}
+	for {
+		exp, newCursor, ok, err := p.parseExpression(cursor, itemEnd, 0)
+		if !ok {
+			return nil, initialCursor, false, err
+		}
+		cursor = newCursor
+
+		item := &OrderItem{Exp: exp}
+		if p.expectToken(cursor, p.tokenFromKeyword(DescKeyword)) {
+			item.Desc = true
+			cursor++
+		} else if p.expectToken(cursor, p.tokenFromKeyword(AscKeyword)) {
+			cursor++
+		}
+
+		// as in postgres nulls are considered larger than any value by default
+		item.NullsFirst = item.Desc
+		if p.expectToken(cursor, p.tokenFromKeyword(NullsKeyword)) {
+			cursor++
+
+			switch {
+			case p.expectToken(cursor, p.tokenFromKeyword(FirstKeyword)):
+				item.NullsFirst = true
+			case p.expectToken(cursor, p.tokenFromKeyword(LastKeyword)):
+				item.NullsFirst = false
+			default:
+				err := p.helpMessage(cursor, "Expected FIRST or LAST after NULLS")
+				return nil, initialCursor, false, err
+			}
+			cursor++
+		}
+
+		items = append(items, item)
+
+		if !p.expectToken(cursor, p.tokenFromSymbol(commaSymbol)) {
+			break
+		}
+		cursor++
+	}
+
+	return items, cursor, true, nil
+}
+
+// parseLimit parses 'LIMIT n [OFFSET m]' or '[OFFSET m [ROWS]] [FETCH FIRST n ROWS ONLY]'.
+func (p *Parser) parseLimit(initialCursor uint, slct *SelectStatement) (uint, bool, error) {
+	cursor := initialCursor
+
+	if p.expectToken(cursor, p.tokenFromKeyword(LimitKeyword)) {
+		cursor++
+
+		limit, newCursor, ok, err := p.parseCount(cursor, "Expected number after LIMIT")
+		if !ok {
+			return initialCursor, false, err
+		}
+
+		slct.Limit = limit
+		slct.IsLimited = true
+		cursor = newCursor
+	}
+
+	if p.expectToken(cursor, p.tokenFromKeyword(OffsetKeyword)) {
+		cursor++
+
+		offset, newCursor, ok, err := p.parseCount(cursor, "Expected number after OFFSET")
+		if !ok {
+			return initialCursor, false, err
+		}
+
+		slct.Offset = offset
+		cursor = p.skipRowsKeyword(newCursor)
+	}
+
+	if !slct.IsLimited && p.expectToken(cursor, p.tokenFromKeyword(FetchKeyword)) {
+		cursor++
+
+		if !p.expectToken(cursor, p.tokenFromKeyword(FirstKeyword)) && !p.expectToken(cursor, p.tokenFromKeyword(NextKeyword)) {
+			err := p.helpMessage(cursor, "Expected FIRST or NEXT after FETCH")
+			return initialCursor, false, err
+		}
+		cursor++
+
+		limit, newCursor, ok, err := p.parseCount(cursor, "Expected number after FETCH FIRST")
+		if !ok {
+			return initialCursor, false, err
+		}
+		cursor = p.skipRowsKeyword(newCursor)
+
+		if !p.expectToken(cursor, p.tokenFromKeyword(OnlyKeyword)) {
+			err := p.helpMessage(cursor, "Expected ONLY")
+			return initialCursor, false, err
+		}
+		cursor++
+
+		slct.Limit = limit
+		slct.IsLimited = true
+	}
+
+	return cursor, true, nil
+}
+
+func (p *Parser) parseCount(initialCursor uint, msg string) (int, uint, bool, error) {
+	t, newCursor, ok := p.parseToken(initialCursor, NumericKind)
 	if !ok {
-		return nil, false, nil
+		return 0, initialCursor, false, p.helpMessage(initialCursor, msg)
+	}
+
+	count, err := strconv.Atoi(t.Value)
+	if err != nil {
+		return 0, initialCursor, false, p.helpMessage(initialCursor, msg)
 	}
 
-	slct.Where = *where
+	return count, newCursor, true, nil
+}
+
+func (p *Parser) skipRowsKeyword(cursor uint) uint {
+	if p.expectToken(cursor, p.tokenFromKeyword(RowKeyword)) || p.expectToken(cursor, p.tokenFromKeyword(RowsKeyword)) {
+		return cursor + 1
+	}
 
-	return &slct, true, nil
+	return cursor
 }
 
 func (p *Parser) parseToken(initialCursor uint, kind TokenKind) (*Token, uint, bool) {
@@ -141,59 +678,8 @@ func (p *Parser) tokenFromSymbol(s symbol) Token {
 	}
 }
 
-func (p *Parser) parseWhere(initialCursor uint, delimiter Token) (*[]interface{}, bool) {
-	cursor := initialCursor
-
-	where := make([]interface{}, 0)
-	i := 0
-	conditions := Conditions{}
-	kinds := []TokenKind{KeywordKind, IdentifierKind, NumericKind, StringKind, OperationKind}
-outer:
-	for {
-		if cursor >= uint(len(p.tokens)) {
-			return nil, false
-		}
-
-		// Look for delimiter
-		current := p.tokens[cursor]
-		if delimiter.equals(current) {
-			break outer
-		}
-
-		for _, kind := range kinds {
-			t, newCursor, ok := p.parseToken(cursor, kind)
-			if ok {
-				if kind == KeywordKind {
-					if Keyword(t.Value) != WhereKeyword {
-						p := Predicate{
-							Predicate: *t,
-						}
-						where = append(where, p)
-					}
-				} else {
-					if i == 0 {
-						conditions.Literal = *t
-						i++
-					} else if i == 1 {
-						conditions.Operation = *t
-						i++
-					} else {
-						conditions.Value = *t
-
-						where = append(where, conditions)
-						i = 0
-						cursor = newCursor
-						continue
-					}
-				}
-				cursor = newCursor
-			}
-		}
-	}
-	return &where, true
-}
-
-func (p *Parser) parseExpressions(initialCursor uint, delimiters []Token) (*[]*Expression, uint, bool, bool, error) {
+// parseExpressions parses a comma separated list, withAlias allows 'exp [AS] alias' items of the select list.
+func (p *Parser) parseExpressions(initialCursor uint, delimiters []Token, withAlias bool) (*[]*Expression, uint, bool, bool, error) {
 	cursor := initialCursor
 
 	isAllItems := false
@@ -232,28 +718,65 @@ outer:
 		}
 
 		// Look for expression
-		exp, newCursor, ok := p.parseExpression(cursor, p.tokenFromSymbol(commaSymbol))
+		exp, newCursor, ok, err := p.parseExpression(cursor, append([]Token{p.tokenFromSymbol(commaSymbol)}, delimiters...), 0)
 		if !ok {
-			err := p.helpMessage(cursor, "Expected expression")
 			return nil, initialCursor, false, isAllItems, err
 		}
 		cursor = newCursor
 
+		if withAlias {
+			alias, newCursor, ok, err := p.parseAlias(cursor)
+			if !ok {
+				return nil, initialCursor, false, isAllItems, err
+			}
+
+			exp.Alias = alias
+			cursor = newCursor
+		}
+
 		exps = append(exps, exp)
 	}
 
 	return &exps, cursor, true, isAllItems, nil
 }
 
-func (p *Parser) parseExpression(initialCursor uint, _ Token) (*Expression, uint, bool) {
+func (p *Parser) parseAlias(initialCursor uint) (string, uint, bool, error) {
 	cursor := initialCursor
 
+	isAs := p.expectToken(cursor, p.tokenFromKeyword(AsKeyword))
+	if isAs {
+		cursor++
+	}
+
+	alias, newCursor, ok := p.parseToken(cursor, IdentifierKind)
+	if !ok {
+		if isAs {
+			return "", initialCursor, false, p.helpMessage(cursor, "Expected alias after AS")
+		}
+
+		return "", initialCursor, true, nil
+	}
+
+	return alias.Value, newCursor, true, nil
+}
+
+func (p *Parser) parseLiteralExpression(initialCursor uint) (*Expression, uint, bool) {
+	cursor := initialCursor
+
+	if p.expectToken(cursor, p.tokenFromKeyword(NullKeyword)) {
+		return &Expression{
+			Literal: p.tokens[cursor],
+			Kind:    LiteralKind,
+		}, cursor + 1, true
+	}
+
 	kinds := []TokenKind{IdentifierKind, NumericKind, StringKind}
 	for _, kind := range kinds {
 		t, newCursor, ok := p.parseToken(cursor, kind)
 		if ok {
 			return &Expression{
 				Literal: t,
+				Kind:    LiteralKind,
 			}, newCursor, true
 		}
 	}
@@ -261,41 +784,132 @@ func (p *Parser) parseExpression(initialCursor uint, _ Token) (*Expression, uint
 	return nil, initialCursor, false
 }
 
+// Parse parses the first statement of source, use ParseScript for many statements.
 func (p *Parser) Parse(source string) (*SelectStatement, error) {
-	var err error
-	p.tokens, err = lex(source)
+	if err := p.lexSource(source); err != nil {
+		return nil, err
+	}
+
+	stmt, _, err := p.parseStatement(0)
 	if err != nil {
 		return nil, err
 	}
 
+	return stmt, nil
+}
+
+// ParseScript parses statements separated by ';', empty statements are skipped.
+func (p *Parser) ParseScript(source string) ([]*Statement, error) {
+	if err := p.lexSource(source); err != nil {
+		return nil, err
+	}
+
+	semicolonToken := p.tokenFromSymbol(semicolonSymbol)
+
+	var statements []*Statement
 	cursor := uint(0)
-	stmt, ok, err := p.parseSelect(cursor)
-	if !ok {
-		er := p.helpMessage(cursor, "Expected statement")
+	previousEnd := uint(0)
+	for cursor < uint(len(p.tokens)) {
+		if p.expectToken(cursor, semicolonToken) {
+			cursor++
+			continue
+		}
+
+		// comments after the last ';' are not tokens, any other text there is not a statement
+		if !p.hasToken(cursor, semicolonToken) {
+			rest := strings.TrimSpace(source[p.tokens[cursor].Loc.Offset:])
+			return nil, fmt.Errorf("statement %d: messageError: Expected ';' at the end of statement, got: %s", len(statements)+1, rest)
+		}
 
+		slct, newCursor, err := p.parseStatement(cursor)
 		if err != nil {
-			return nil, fmt.Errorf("messageErrorParseSelect: %v", err)
+			return nil, fmt.Errorf("statement %d: %v", len(statements)+1, err)
 		}
-		return nil, fmt.Errorf("messageError: %v", er)
+
+		start, end := p.tokens[cursor].Loc.Offset, p.tokens[newCursor].Loc.Offset
+		statements = append(statements, &Statement{
+			Select:   slct,
+			Source:   source[start : end+1],
+			Comments: p.commentsBetween(previousEnd, start),
+		})
+		cursor = newCursor + 1
+		previousEnd = end
 	}
 
-	return stmt, nil
+	if len(statements) == 0 {
+		return nil, fmt.Errorf("messageError: Expected statement")
+	}
+
+	return statements, nil
 }
 
-func (p *Parser) parseSelect(initialCursor uint) (*SelectStatement, bool, error) {
-	cursor := initialCursor
+// hasToken reports whether the token is found from cursor to the end.
+func (p *Parser) hasToken(cursor uint, token Token) bool {
+	for ; cursor < uint(len(p.tokens)); cursor++ {
+		if p.expectToken(cursor, token) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// lexSource splits source into tokens of statements and comments.
+func (p *Parser) lexSource(source string) error {
+	tokens, err := lex(source)
+	if err != nil {
+		return err
+	}
+
+	p.tokens, p.comments = nil, nil
+	for _, token := range tokens {
+		if token.Kind == CommentKind {
+			p.comments = append(p.comments, token)
+			continue
+		}
+
+		p.tokens = append(p.tokens, token)
+	}
+
+	return nil
+}
+
+// commentsBetween returns texts of comments from the offset start up to end, the leading
+// comments of a statement are the ones after the previous statement.
+func (p *Parser) commentsBetween(start, end uint) []string {
+	var comments []string
+	for _, comment := range p.comments {
+		if comment.Loc.Offset >= start && comment.Loc.Offset < end {
+			comments = append(comments, comment.Value)
+		}
+	}
+
+	return comments
+}
+
+// parseStatement parses a statement ending with ';', the returned cursor points to ';'.
+func (p *Parser) parseStatement(cursor uint) (*SelectStatement, uint, error) {
+	if cursor >= uint(len(p.tokens)) {
+		return nil, cursor, fmt.Errorf("messageError: Expected statement")
+	}
 
 	semicolonToken := p.tokenFromSymbol(semicolonSymbol)
-	slct, ok, err := p.parseSelectStatement(cursor, semicolonToken)
-	if ok {
-		return slct, true, err
+	slct, newCursor, ok, err := p.parseSelectStatement(cursor, semicolonToken)
+	if !ok {
+		if err != nil {
+			return nil, cursor, fmt.Errorf("messageErrorParseSelect: %v", err)
+		}
+
+		return nil, cursor, fmt.Errorf("messageError: %v", p.helpMessage(cursor, "Expected statement"))
 	}
 
-	return nil, false, err
+	return slct, newCursor, nil
 }
 
 type Parser struct {
 	tokens []*Token
+	// comments are kept apart from tokens, they are not a part of statements
+	comments []*Token
 }
 
 func NewParser() Parser {
@@ -14,11 +14,47 @@ func TestParseOK(t *testing.T) {
 	}{
 		{
 			InRequest: "select * from table where col1 > 6 and col2 = 'test';",
-			OutData:   CreateOutData(true, []string{}, []string{"col1", ">", "6"}, []string{"col2", "=", "test"}, "table", "and"),
+			OutData:   CreateOutData(true, []string{}, "table", "((col1 > 6) and (col2 = 'test'))"),
 		},
 		{
 			InRequest: "select col1, col2 from table where col1 >= 6;",
-			OutData:   CreateOutData(false, []string{"col1", "col2"}, []string{"col1", ">=", "6"}, []string{}, "table", ""),
+			OutData:   CreateOutData(false, []string{"col1", "col2"}, "table", "(col1 >= 6)"),
+		},
+		{
+			InRequest: "select * from table where col1 = 1 or col2 = 2 and col3 = 3;",
+			OutData:   CreateOutData(true, []string{}, "table", "((col1 = 1) or ((col2 = 2) and (col3 = 3)))"),
+		},
+		{
+			InRequest: "select * from table where (status = 'f' or status = 'c') and magnitude >= 6;",
+			OutData:   CreateOutData(true, []string{}, "table", "(((status = 'f') or (status = 'c')) and (magnitude >= 6))"),
+		},
+		{
+			InRequest: "select * from table where not (col1 = 1 or col2 <> 2) and notes = 'x';",
+			OutData:   CreateOutData(true, []string{}, "table", "((not ((col1 = 1) or (col2 != 2))) and (notes = 'x'))"),
+		},
+		{
+			InRequest: "select * from table where status not in ('f', 'c') or units not like 'dol%';",
+			OutData:   CreateOutData(true, []string{}, "table", "((not (status in ('f', 'c'))) or (not (units like 'dol%')))"),
+		},
+		{
+			InRequest: "select * from table where magnitude not between 3 and 6 and index in (1);",
+			OutData:   CreateOutData(true, []string{}, "table", "((not (magnitude between 3 and 6)) and (index in (1)))"),
+		},
+		{
+			InRequest: "select * from table where a between 1 and 2 + 3 and b in (1, 'x') or c between -1 and 1;",
+			OutData:   CreateOutData(true, []string{}, "table", "(((a between 1 and (2 + 3)) and (b in (1, 'x'))) or (c between (-1) and 1))"),
+		},
+		{
+			InRequest: "select * from business;",
+			OutData:   CreateOutData(true, []string{}, "business", ""),
+		},
+		{
+			InRequest: "select data_value * 1000, -magnitude + 2 * 3 % 4 from business where magnitude - 3 > 2 and 1 = -1;",
+			OutData:   CreateOutData(false, []string{"(data_value * 1000)", "((-magnitude) + ((2 * 3) % 4))"}, "business", "(((magnitude - 3) > 2) and (1 = (-1)))"),
+		},
+		{
+			InRequest: "select 1, 'a' where 1 = 1;",
+			OutData:   CreateOutData(false, []string{"1", "'a'"}, "", "(1 = 1)"),
 		},
 	}
 
@@ -37,8 +73,8 @@ func TestParseFAIL(t *testing.T) {
 		Error     error
 	}{
 		{
-			InRequest: "select col col from table where col1 > 6 and col2 = 'test';",
-			Error:     fmt.Errorf("messageErrorParseSelect: Expected comma, got: col"),
+			InRequest: "select col col1 col2 from table where col1 > 6 and col2 = 'test';",
+			Error:     fmt.Errorf("messageErrorParseSelect: Expected comma, got: col2"),
 		},
 		{
 			InRequest: "select col1, col2 from where col1 >= 6;",
@@ -48,10 +84,30 @@ func TestParseFAIL(t *testing.T) {
 			InRequest: "select col1, from table where col1 > 6 and col2 = 'test';",
 			Error:     fmt.Errorf("messageErrorParseSelect: Expected expression, got: from"),
 		},
+		{
+			InRequest: "select col1 from table where;",
+			Error:     fmt.Errorf("messageErrorParseSelect: Expected expression, got: ;"),
+		},
 		{
 			InRequest: "col1, col2 from table where col1 >= 6;",
 			Error:     fmt.Errorf("messageErrorParseSelect: Expected SELECT statement"),
 		},
+		{
+			InRequest: "select * from table where (col1 = 1 or col2 = 2;",
+			Error:     fmt.Errorf("messageErrorParseSelect: Expected closing parenthesis, got: ;"),
+		},
+		{
+			InRequest: "select * from table where col1 = 1 col2 = 2;",
+			Error:     fmt.Errorf("messageErrorParseSelect: Expected ';', got: col2"),
+		},
+		{
+			InRequest: "select * from table where col1 not = 1;",
+			Error:     fmt.Errorf("messageErrorParseSelect: Expected IN, LIKE, ILIKE, REGEXP or BETWEEN after NOT, got: ="),
+		},
+		{
+			InRequest: "select * from table where col1 between 1 or 2;",
+			Error:     fmt.Errorf("messageErrorParseSelect: Expected AND in BETWEEN, got: or"),
+		},
 	}
 
 	p := NewParser()
@@ -62,58 +118,477 @@ func TestParseFAIL(t *testing.T) {
 	}
 }
 
-//block helpers
+func TestParseOrderBy(t *testing.T) {
+	testData := []struct {
+		InRequest string
+		OrderBy   []string
+	}{
+		{
+			InRequest: "select * from business order by status;",
+			OrderBy:   []string{"status asc nulls last"},
+		},
+		{
+			InRequest: "select * from business where magnitude > 1 order by status desc, magnitude asc nulls first, units desc nulls last;",
+			OrderBy:   []string{"status desc nulls first", "magnitude asc nulls first", "units desc nulls last"},
+		},
+	}
+
+	p := NewParser()
+	for _, data := range testData {
+		out, err := p.Parse(data.InRequest)
+		assert.Equal(t, err, nil)
+
+		items := make([]string, 0, len(out.OrderBy))
+		for _, item := range out.OrderBy {
+			direction, nulls := "asc", "last"
+			if item.Desc {
+				direction = "desc"
+			}
+			if item.NullsFirst {
+				nulls = "first"
+			}
+
+			items = append(items, fmt.Sprintf("%s %s nulls %s", item.Exp, direction, nulls))
+		}
+
+		assert.Equal(t, data.OrderBy, items)
+	}
+
+	_, err := p.Parse("select * from business order status;")
+	assert.Equal(t, fmt.Errorf("messageErrorParseSelect: Expected BY after ORDER, got: status"), err)
+}
+
+func TestParseLimit(t *testing.T) {
+	testData := []struct {
+		InRequest string
+		IsLimited bool
+		Limit     int
+		Offset    int
+	}{
+		{
+			InRequest: "select * from business limit 10;",
+			IsLimited: true,
+			Limit:     10,
+		},
+		{
+			InRequest: "select * from business where magnitude > 1 order by status limit 5 offset 20;",
+			IsLimited: true,
+			Limit:     5,
+			Offset:    20,
+		},
+		{
+			InRequest: "select * from business offset 3 rows fetch first 1 row only;",
+			IsLimited: true,
+			Limit:     1,
+			Offset:    3,
+		},
+		{
+			InRequest: "select * from business offset 3;",
+			Offset:    3,
+		},
+	}
+
+	p := NewParser()
+	for _, data := range testData {
+		out, err := p.Parse(data.InRequest)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, data.IsLimited, out.IsLimited)
+		assert.Equal(t, data.Limit, out.Limit)
+		assert.Equal(t, data.Offset, out.Offset)
+	}
+
+	_, err := p.Parse("select * from business limit all;")
+	assert.Equal(t, fmt.Errorf("messageErrorParseSelect: Expected number after LIMIT, got: all"), err)
+
+	_, err = p.Parse("select * from business fetch first 10 rows;")
+	assert.Equal(t, fmt.Errorf("messageErrorParseSelect: Expected ONLY, got: ;"), err)
+}
+
+func TestParseGroupBy(t *testing.T) {
+	testData := []struct {
+		InRequest string
+		Columns   []string
+		GroupBy   []string
+	}{
+		{
+			InRequest: "select status, count(*), sum(data_value) from business where magnitude > 1 group by status;",
+			Columns:   []string{"status", "count(*)", "sum(data_value)"},
+			GroupBy:   []string{"status"},
+		},
+		{
+			InRequest: "select COUNT(DISTINCT units), max(magnitude) from business group by subject, \"Group\" order by count(*) desc;",
+			Columns:   []string{"count(distinct units)", "max(magnitude)"},
+			GroupBy:   []string{"subject", "group"},
+		},
+	}
+
+	p := NewParser()
+	for _, data := range testData {
+		out, err := p.Parse(data.InRequest)
+		assert.Equal(t, err, nil)
+
+		columns := make([]string, 0, len(out.Item))
+		for _, item := range out.Item {
+			columns = append(columns, item.String())
+		}
+
+		groupBy := make([]string, 0, len(out.GroupBy))
+		for _, item := range out.GroupBy {
+			groupBy = append(groupBy, item.String())
+		}
+
+		assert.Equal(t, data.Columns, columns)
+		assert.Equal(t, data.GroupBy, groupBy)
+	}
+
+	_, err := p.Parse("select count(*) from business group by;")
+	assert.Equal(t, fmt.Errorf("messageErrorParseSelect: Expected expression after GROUP BY, got: ;"), err)
+
+	out, err := p.Parse("select status from business group by status having count(*) > 100 and max(magnitude) >= 6 order by status;")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, "((count(*) > 100) and (max(magnitude) >= 6))", out.Having.String())
+
+	_, err = p.Parse("select count(* from business;")
+	assert.Equal(t, fmt.Errorf("messageErrorParseSelect: Expected closing parenthesis, got: from"), err)
+}
+
+func TestParseDistinct(t *testing.T) {
+	p := NewParser()
+
+	out, err := p.Parse("select distinct subject, \"Group\" from business;")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, true, out.Distinct)
+	assert.Equal(t, 0, len(out.DistinctOn))
+
+	out, err = p.Parse("select distinct on (series_reference, units) series_reference, period from business order by series_reference, period desc;")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, false, out.Distinct)
+	assert.Equal(t, 2, len(out.DistinctOn))
+	assert.Equal(t, "series_reference", out.DistinctOn[0].String())
+	assert.Equal(t, "units", out.DistinctOn[1].String())
+
+	_, err = p.Parse("select distinct on series_reference from business;")
+	assert.Equal(t, fmt.Errorf("messageErrorParseSelect: Expected opening parenthesis, got: series_reference"), err)
+}
+
+func TestParseAlias(t *testing.T) {
+	p := NewParser()
+
+	out, err := p.Parse("select status as s, count(*) total, units from business group by s order by total desc;")
+	assert.Equal(t, err, nil)
+
+	aliases := make([]string, 0, len(out.Item))
+	for _, item := range out.Item {
+		aliases = append(aliases, item.Alias)
+	}
+
+	assert.Equal(t, []string{"s", "total", ""}, aliases)
+	assert.Equal(t, "count(*)", out.Item[1].String())
+
+	_, err = p.Parse("select status as from business;")
+	assert.Equal(t, fmt.Errorf("messageErrorParseSelect: Expected alias after AS, got: from"), err)
+}
+
+func TestParseFunctions(t *testing.T) {
+	p := NewParser()
+
+	out, err := p.Parse("select upper(status) || '-' || units, substr(series_reference, 1, 2 + 2) from business where 'a' || 1 + 2 = 'a3';")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, "((upper(status) || '-') || units)", out.Item[0].String())
+	assert.Equal(t, "substr(series_reference, 1, (2 + 2))", out.Item[1].String())
+	assert.Equal(t, "(('a' || (1 + 2)) = 'a3')", out.Where.String())
+
+	_, err = p.Parse("select upper(status from business;")
+	assert.Equal(t, fmt.Errorf("messageErrorParseSelect: Expected closing parenthesis, got: from"), err)
+}
+
+func TestParseLike(t *testing.T) {
+	p := NewParser()
+
+	out, err := p.Parse("select * from business where units ILIKE 'DOL%' and status not like '10!%' escape '!' or units like status || '%';")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, "(((units ilike 'DOL%') and (not (status like '10!%' escape '!'))) or (units like (status || '%')))", out.Where.String())
+
+	_, err = p.Parse("select * from business where units like 'a%' escape;")
+	assert.Equal(t, fmt.Errorf("messageErrorParseSelect: Expected expression, got: ;"), err)
+}
+
+func TestParseIsNull(t *testing.T) {
+	p := NewParser()
+
+	out, err := p.Parse("select null, coalesce(units, null) from business where suppressed is null and data_value + 1 is not null or nullif(status, 'F') IS NOT NULL;")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, "null", out.Item[0].String())
+	assert.Equal(t, "coalesce(units, null)", out.Item[1].String())
+	assert.Equal(t, "(((suppressed is null) and (not ((data_value + 1) is null))) or (not (nullif(status, 'F') is null)))", out.Where.String())
+
+	_, err = p.Parse("select * from business where suppressed is 'Y';")
+	assert.Equal(t, fmt.Errorf("messageErrorParseSelect: Expected NULL after IS, got: Y"), err)
+}
+
+func TestParseRegexp(t *testing.T) {
+	p := NewParser()
+
+	out, err := p.Parse("select * from business where series_reference ~ '^BDCQ' and units !~* 'dol' or status not regexp '[FC]';")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, "(((series_reference ~ '^BDCQ') and (units !~* 'dol')) or (not (status regexp '[FC]')))", out.Where.String())
+
+	_, err = p.Parse("select * from business where series_reference ~* 'BDCQ(';")
+	assert.Equal(t, fmt.Errorf("messageErrorParseSelect: invalid regular expression 'BDCQ(': error parsing regexp: missing closing ): `BDCQ(`"), err)
+}
+
+func TestParseCase(t *testing.T) {
+	p := NewParser()
+
+	out, err := p.Parse("select case when magnitude >= 6 then 'large' when magnitude between 3 and 5 then 'medium' else 'small' end as size, case status when 'F' then 1 end from business where case when status is null then 0 else 1 end = 1;")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, "case when (magnitude >= 6) then 'large' when (magnitude between 3 and 5) then 'medium' else 'small' end", out.Item[0].String())
+	assert.Equal(t, "size", out.Item[0].Alias)
+	assert.Equal(t, "case status when 'F' then 1 end", out.Item[1].String())
+	assert.Equal(t, "(case when (status is null) then 0 else 1 end = 1)", out.Where.String())
+
+	_, err = p.Parse("select case when status = 'F' 1 end from business;")
+	assert.Equal(t, fmt.Errorf("messageErrorParseSelect: Expected THEN, got: 1"), err)
+
+	_, err = p.Parse("select case status end from business;")
+	assert.Equal(t, fmt.Errorf("messageErrorParseSelect: Expected WHEN, got: end"), err)
+
+	_, err = p.Parse("select case when status = 'F' then 1 from business;")
+	assert.Equal(t, fmt.Errorf("messageErrorParseSelect: Expected END, got: from"), err)
+}
+
+func TestParseJoin(t *testing.T) {
+	p := NewParser()
+
+	out, err := p.Parse("select b.status, s.description from business b left outer join statuses s on b.status = s.status and s.description != 'Final' cross join units where b.magnitude > 3;")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, "business", out.From.Value)
+	assert.Equal(t, "b", out.FromAlias)
+	assert.Equal(t, "b.status", out.Item[0].String())
+	assert.Equal(t, 2, len(out.Joins))
+	assert.Equal(t, LeftJoin, out.Joins[0].Kind)
+	assert.Equal(t, "statuses", out.Joins[0].Table.Value)
+	assert.Equal(t, "s", out.Joins[0].Alias)
+	assert.Equal(t, "((b.status = s.status) and (s.description != 'Final'))", out.Joins[0].On.String())
+	assert.Equal(t, CrossJoin, out.Joins[1].Kind)
+	assert.Nil(t, out.Joins[1].On)
+	assert.Equal(t, "(b.magnitude > 3)", out.Where.String())
+
+	_, err = p.Parse("select * from business join statuses;")
+	assert.Equal(t, fmt.Errorf("messageErrorParseSelect: Expected ON, got: ;"), err)
+
+	_, err = p.Parse("select * from business full statuses on status = status;")
+	assert.Equal(t, fmt.Errorf("messageErrorParseSelect: Expected JOIN, got: statuses"), err)
+}
+
+func TestParseSubquery(t *testing.T) {
+	p := NewParser()
+
+	out, err := p.Parse("select status, (select count(*) from statuses s where s.status = b.status) from (select * from business where magnitude > 3) as b where status in (select status from statuses) and not exists (select 1 from units);")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, "(select count(*) from statuses s where (s.status = b.status))", out.Item[1].String())
+	assert.Equal(t, "select * from business where (magnitude > 3)", out.FromSubquery.String())
+	assert.Equal(t, "b", out.FromAlias)
+	assert.Equal(t, "((status in (select status from statuses)) and (not exists (select 1 from units)))", out.Where.String())
+
+	out, err = p.Parse("select * from business b join (select status, count(*) as n from business group by status order by n desc limit 2) t on b.status = t.status;")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, "select status, count(*) as n from business group by status order by n desc limit 2", out.Joins[0].Subquery.String())
+	assert.Equal(t, "t", out.Joins[0].Alias)
+
+	_, err = p.Parse("select * from (select * from business);")
+	assert.Equal(t, fmt.Errorf("messageErrorParseSelect: Expected alias for subquery in FROM, got: ;"), err)
+
+	_, err = p.Parse("select * from business where exists (select * from business;")
+	assert.Equal(t, fmt.Errorf("messageErrorParseSelect: Expected ')', got: ;"), err)
+
+	_, err = p.Parse("select * from business where exists status;")
+	assert.Equal(t, fmt.Errorf("messageErrorParseSelect: Expected subquery after EXISTS, got: status"), err)
+}
+
+func TestParseSetOperations(t *testing.T) {
+	p := NewParser()
+
+	out, err := p.Parse("select status from business union all select status from statuses except select 'P' intersect select status from units order by status desc limit 2;")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, Except, out.Compound.Kind)
+	assert.Equal(t, "(select status from business union all select status from statuses) except (select 'P' intersect select status from units) order by status desc limit 2", out.String())
+	assert.Equal(t, true, out.Compound.Left.Compound.All)
+	assert.Equal(t, Intersect, out.Compound.Right.Compound.Kind)
+
+	out, err = p.Parse("select status from business where status in (select status from statuses union select 'C') union (select status from units limit 1);")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, "(status in (select status from statuses union select 'C'))", out.Compound.Left.Where.String())
+	assert.Equal(t, "select status from units limit 1", out.Compound.Right.String())
+
+	_, err = p.Parse("select status from business order by status union select status from statuses;")
+	assert.Equal(t, fmt.Errorf("messageErrorParseSelect: Expected ';', got: union"), err)
+
+	_, err = p.Parse("select status from business union;")
+	assert.Equal(t, fmt.Errorf("messageErrorParseSelect: Expected SELECT statement"), err)
+}
+
+func TestParseWith(t *testing.T) {
+	p := NewParser()
+
+	out, err := p.Parse("with recursive t(n, total) as (select 1, 1 union all select n + 1, total * 2 from t where n < 5), big as (select * from business where magnitude > 6) select n from t union select magnitude from big;")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, true, out.With.Recursive)
+	assert.Equal(t, 2, len(out.With.Tables))
+	assert.Equal(t, []string{"n", "total"}, out.With.Tables[0].Columns)
+	assert.Equal(t, "select 1, 1 union all select (n + 1), (total * 2) from t where (n < 5)", out.With.Tables[0].Select.String())
+	assert.Equal(t, "big", out.With.Tables[1].Name)
+	assert.Equal(t, Union, out.Compound.Kind)
+
+	out, err = p.Parse("select * from business where status in (with s as (select 'F') select * from s);")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, "(status in (with s as (select 'F') select * from s))", out.Where.String())
+
+	_, err = p.Parse("with t select 1;")
+	assert.Equal(t, fmt.Errorf("messageErrorParseSelect: Expected AS, got: select"), err)
+
+	_, err = p.Parse("with t as select 1;")
+	assert.Equal(t, fmt.Errorf("messageErrorParseSelect: Expected subquery after AS, got: select"), err)
+}
+
+// block helpers
 func EqualOutData(t *testing.T, sel *SelectStatement, out OutData) {
 	assert.Equal(t, out.IsAll, sel.IsAllItems)
 	assert.Equal(t, out.Table, sel.From.Value)
 
 	items := make([]string, 0, len(sel.Item))
 	for _, val := range sel.Item {
-		items = append(items, val.Literal.Value)
+		items = append(items, val.String())
 	}
 
 	assert.ElementsMatch(t, items, out.Columns)
 
-	var con []string
-	for _, val := range sel.Where {
-		item, ok := val.(Predicate)
-
-		if ok {
-			assert.Equal(t, out.Predicate, item.Predicate.Value)
-		} else {
-			eq := out.Condition1
-			if len(con) != 0 {
-				eq = out.Condition2
-				con = []string{}
-			}
-
-			item, ok := val.(Conditions)
-
-			if ok {
-				con = append(con, item.Literal.Value, item.Operation.Value, item.Value.Value)
-			}
-
-			assert.ElementsMatch(t, eq, con)
-		}
+	where := ""
+	if sel.Where != nil {
+		where = sel.Where.String()
 	}
+
+	assert.Equal(t, out.Where, where)
 }
 
 type OutData struct {
-	IsAll      bool
-	Columns    []string
-	Table      string
-	Condition1 []string
-	Predicate  string
-	Condition2 []string
+	IsAll   bool
+	Columns []string
+	Table   string
+	Where   string
 }
 
-func CreateOutData(isAll bool, columns, con1, con2 []string, table, predicate string) OutData {
+func CreateOutData(isAll bool, columns []string, table, where string) OutData {
 	return OutData{
-		IsAll:      isAll,
-		Columns:    columns,
-		Table:      table,
-		Condition1: con1,
-		Predicate:  predicate,
-		Condition2: con2,
+		IsAll:   isAll,
+		Columns: columns,
+		Table:   table,
+		Where:   where,
 	}
 }
+
+func TestParseWindow(t *testing.T) {
+	p := NewParser()
+
+	out, err := p.Parse("select sum(data_value) over (partition by status, units order by period desc rows between unbounded preceding and 1 following), row_number() over () from business;")
+	assert.Equal(t, err, nil)
+	over := out.Item[0].Function.Over
+	assert.Equal(t, 2, len(over.PartitionBy))
+	assert.Equal(t, true, over.OrderBy[0].Desc)
+	assert.Equal(t, &Frame{Start: FrameBound{Kind: UnboundedPreceding}, End: FrameBound{Kind: Following, Offset: 1}}, over.Frame)
+	assert.Equal(t, "sum(data_value) over (partition by status, units order by period desc rows between unbounded preceding and 1 following)", out.Item[0].String())
+	assert.Equal(t, "row_number() over ()", out.Item[1].String())
+
+	out, err = p.Parse("select lag(data_value, 2) over (order by period range 3 preceding) from business;")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, "lag(data_value, 2) over (order by period range between 3 preceding and current row)", out.Item[0].String())
+
+	_, err = p.Parse("select rank() over order by period from business;")
+	assert.Equal(t, fmt.Errorf("messageErrorParseSelect: Expected opening parenthesis after OVER, got: order"), err)
+
+	_, err = p.Parse("select rank() over (order by period rows current) from business;")
+	assert.Equal(t, fmt.Errorf("messageErrorParseSelect: Expected ROW after CURRENT, got: )"), err)
+
+	_, err = p.Parse("select count(*) over (rows between current row and 1 preceding) from business;")
+	assert.Equal(t, fmt.Errorf("messageErrorParseSelect: frame starting from current row cannot end with 1 preceding"), err)
+}
+
+func TestParseScript(t *testing.T) {
+	p := NewParser()
+
+	out, err := p.ParseScript("select * from business;\n;select status,\n count(*) from business group by status; ")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, 2, len(out))
+	assert.Equal(t, "select * from business;", out[0].Source)
+	assert.Equal(t, "select status,\n count(*) from business group by status;", out[1].Source)
+	assert.Equal(t, "select status, count(*) from business group by status", out[1].Select.String())
+
+	_, err = p.ParseScript("select 1; select * business;")
+	assert.Equal(t, fmt.Errorf("statement 2: messageErrorParseSelect: Expected ';', got: business"), err)
+
+	_, err = p.ParseScript("select 1; select 2")
+	assert.Equal(t, fmt.Errorf("statement 2: messageError: Expected ';' at the end of statement, got: select 2"), err)
+
+	_, err = p.ParseScript("select 1; select 2; trailing\n")
+	assert.Equal(t, fmt.Errorf("statement 3: messageError: Expected ';' at the end of statement, got: trailing"), err)
+
+	out, err = p.ParseScript("select 1; select 2; -- done; really\n/* end; */ ")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, 2, len(out))
+
+	_, err = p.ParseScript(" ; ")
+	assert.Equal(t, fmt.Errorf("messageError: Expected statement"), err)
+}
+
+func TestParseComments(t *testing.T) {
+	p := NewParser()
+
+	out, err := p.ParseScript("-- all rows\nselect * from business; /* statuses\n of business */ select status -- only status\nfrom business where magnitude > 6/*big*/;")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, 2, len(out))
+	assert.Equal(t, []string{"all rows"}, out[0].Comments)
+	assert.Equal(t, []string{"statuses\n of business"}, out[1].Comments)
+	assert.Equal(t, "select status from business where (magnitude > 6)", out[1].Select.String())
+
+	stmt, err := p.Parse("select country/*name*/ from business;")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, "country", stmt.Item[0].Literal.Value)
+
+	tokens, err := lex("/* a\nb */ select\n-- c\n1")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, Location{Line: 1, Col: 5, Offset: 10}, tokens[1].Loc)
+	assert.Equal(t, Location{Line: 2, Col: 0, Offset: 17}, tokens[2].Loc)
+	assert.Equal(t, Location{Line: 3, Col: 0, Offset: 22}, tokens[3].Loc)
+
+	_, err = p.Parse("select 1 /* open;")
+	assert.Equal(t, fmt.Errorf("unterminated comment, at 0:9"), err)
+}
+
+func TestParseDate(t *testing.T) {
+	p := NewParser()
+
+	stmt, err := p.Parse("select extract(month from period), date '2016-06-01', timestamp '2016-06-01 10:00:00' from business where date = 1;")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, "select extract(month from period), date '2016-06-01', timestamp '2016-06-01 10:00:00' from business where (date = 1)", stmt.String())
+	assert.Equal(t, DateKind, stmt.Item[1].Literal.Kind)
+
+	_, err = p.Parse("select extract(1 from period) from business;")
+	assert.Equal(t, fmt.Errorf("messageErrorParseSelect: Expected field of EXTRACT, got: 1"), err)
+}
+
+func TestLexDivision(t *testing.T) {
+	tokens, err := lex("a/2")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, []*Token{
+		{Value: "a", Kind: IdentifierKind, Loc: Location{Col: 0, Offset: 0}},
+		{Value: "/", Kind: OperationKind, Loc: Location{Col: 1, Offset: 1}},
+		{Value: "2", Kind: NumericKind, Loc: Location{Col: 2, Offset: 2}},
+	}, tokens)
+
+	p := NewParser()
+	out, err := p.Parse("select magnitude/2, \"Country/Region\" from business;")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, "(magnitude / 2)", out.Item[0].String())
+	assert.Equal(t, "country/region", out.Item[1].String())
+}
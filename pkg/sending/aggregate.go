@@ -0,0 +1,352 @@
+package sending
+
+import (
+	"course_project/pkg/parsing"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+type aggregateFunction string
+
+const (
+	countAggregate aggregateFunction = "count"
+	sumAggregate   aggregateFunction = "sum"
+	avgAggregate   aggregateFunction = "avg"
+	minAggregate   aggregateFunction = "min"
+	maxAggregate   aggregateFunction = "max"
+)
+
+func isAggregate(exp *parsing.Expression) bool {
+	if exp.Kind != parsing.FunctionKind || exp.Function.Over != nil {
+		return false
+	}
+
+	switch aggregateFunction(exp.Function.Name.Value) {
+	case countAggregate, sumAggregate, avgAggregate, minAggregate, maxAggregate:
+		return true
+	}
+
+	return false
+}
+
+// collectAggregates appends aggregate calls found in exp to aggregates, skipping
+// the ones already collected.
+func collectAggregates(exp *parsing.Expression, aggregates []*parsing.Expression) ([]*parsing.Expression, error) {
+	if isAggregate(exp) {
+		for _, arg := range exp.Function.Args {
+			nested, err := collectAggregates(arg, nil)
+			if err != nil {
+				return aggregates, err
+			}
+
+			if len(nested) != 0 {
+				return aggregates, fmt.Errorf("aggregate function calls cannot be nested: '%s'", exp)
+			}
+		}
+
+		for _, found := range aggregates {
+			if found.String() == exp.String() {
+				return aggregates, nil
+			}
+		}
+
+		return append(aggregates, exp), nil
+	}
+
+	var err error
+	for _, child := range exp.Children() {
+		aggregates, err = collectAggregates(child, aggregates)
+		if err != nil {
+			return aggregates, err
+		}
+	}
+
+	return aggregates, nil
+}
+
+func (c *CsvParser) findAggregates(request *parsing.SelectStatement) ([]*parsing.Expression, error) {
+	var aggregates []*parsing.Expression
+	var err error
+
+	for _, item := range request.Item {
+		aggregates, err = collectAggregates(item, aggregates)
+		if err != nil {
+			return aggregates, err
+		}
+	}
+
+	if request.Having != nil {
+		aggregates, err = collectAggregates(request.Having, aggregates)
+		if err != nil {
+			return aggregates, err
+		}
+	}
+
+	for _, item := range request.OrderBy {
+		aggregates, err = collectAggregates(item.Exp, aggregates)
+		if err != nil {
+			return aggregates, err
+		}
+	}
+
+	for _, item := range request.DistinctOn {
+		aggregates, err = collectAggregates(item, aggregates)
+		if err != nil {
+			return aggregates, err
+		}
+	}
+
+	return aggregates, nil
+}
+
+// checkGrouped checks that columns used after grouping are GROUP BY expressions
+// or arguments of aggregate functions.
+func checkGrouped(request *parsing.SelectStatement) error {
+	grouped := map[string]bool{}
+	for _, exp := range request.GroupBy {
+		grouped[exp.String()] = true
+	}
+
+	exps := append([]*parsing.Expression{}, request.Item...)
+	if request.Having != nil {
+		exps = append(exps, request.Having)
+	}
+	for _, item := range request.OrderBy {
+		exps = append(exps, item.Exp)
+	}
+	exps = append(exps, request.DistinctOn...)
+
+	for _, exp := range exps {
+		if name, ok := findUngrouped(exp, grouped); !ok {
+			return fmt.Errorf("column '%s' must appear in the GROUP BY clause or be used in an aggregate function", name)
+		}
+	}
+
+	return nil
+}
+
+func findUngrouped(exp *parsing.Expression, grouped map[string]bool) (string, bool) {
+	if grouped[exp.String()] || isAggregate(exp) {
+		return "", true
+	}
+
+	if exp.Kind == parsing.LiteralKind && exp.Literal.Kind == parsing.IdentifierKind {
+		return exp.Literal.Value, false
+	}
+
+	for _, child := range exp.Children() {
+		if name, ok := findUngrouped(child, grouped); !ok {
+			return name, false
+		}
+	}
+
+	return "", true
+}
+
+// aggregate groups rows by GROUP BY, filters groups by HAVING and returns a parser over them.
+// Its columns are the group expressions and the aggregate calls, both named and looked up by their text.
+func (c *CsvParser) aggregate(rows [][]string, request *parsing.SelectStatement, aggregates []*parsing.Expression) (*CsvParser, error) {
+	if request.IsAllItems {
+		return c, fmt.Errorf("SELECT * cannot be used with GROUP BY or aggregate functions")
+	}
+
+	if err := checkGrouped(request); err != nil {
+		return c, err
+	}
+
+	// aggregates of no values are empty cells
+	model := &CsvModel{computed: map[string]int{}, nullValues: map[string]bool{"": true}}
+	for _, exp := range append(append([]*parsing.Expression{}, request.GroupBy...), aggregates...) {
+		model.computed[exp.String()] = len(model.columnsName)
+
+		// a grouped column keeps its table, so both 'status' and 'b.status' refer to it
+		name, qualifier := exp.String(), ""
+		if idx := c.csvModel.GetIdxColumnName(name); isColumn(exp) && idx != -1 && c.csvModel.qualifiers != nil {
+			name, qualifier = c.csvModel.columnsName[idx], c.csvModel.qualifiers[idx]
+		}
+
+		model.columnsName = append(model.columnsName, name)
+		model.qualifiers = append(model.qualifiers, qualifier)
+	}
+
+	var keys []string
+	keyValues := map[string][]string{}
+	groups := map[string][][]string{}
+
+	if len(request.GroupBy) == 0 {
+		// all rows are one group, even when there are no rows
+		keys = append(keys, "")
+		groups[""] = rows
+	} else {
+		for _, str := range rows {
+			var values []string
+			for _, exp := range request.GroupBy {
+				val, err := c.evaluate(exp, str)
+				if err != nil {
+					return c, err
+				}
+
+				values = append(values, val.String())
+			}
+
+			key := strings.Join(values, "\x00")
+			if _, ok := groups[key]; !ok {
+				keys = append(keys, key)
+				keyValues[key] = values
+			}
+
+			groups[key] = append(groups[key], str)
+		}
+	}
+
+	for _, key := range keys {
+		row := append([]string{}, keyValues[key]...)
+
+		for _, exp := range aggregates {
+			val, err := c.computeAggregate(exp.Function, groups[key])
+			if err != nil {
+				return c, err
+			}
+
+			row = append(row, val)
+		}
+
+		model.data = append(model.data, row)
+	}
+
+	grouped := c.derive(model)
+
+	// HAVING filters groups with the same evaluator as WHERE does rows
+	filtered, err := grouped.filterRows(request.Having, -1)
+	if err != nil {
+		return c, err
+	}
+	model.data = filtered
+
+	return grouped, nil
+}
+
+// computeAggregate skips empty cells, for an empty set COUNT returns 0 and the others an empty cell.
+func (c *CsvParser) computeAggregate(function *parsing.FunctionCall, rows [][]string) (string, error) {
+	name := aggregateFunction(function.Name.Value)
+
+	// arguments are checked by bindRequest
+	if function.IsAllArgs {
+		return strconv.Itoa(len(rows)), nil
+	}
+
+	var values []string
+	seen := map[string]bool{}
+	for _, str := range rows {
+		val, err := c.evaluate(function.Args[0], str)
+		if err != nil {
+			return "", err
+		}
+
+		if isNull(val) {
+			continue
+		}
+
+		if function.Distinct {
+			if seen[val.String()] {
+				continue
+			}
+			seen[val.String()] = true
+		}
+
+		values = append(values, val.String())
+	}
+
+	if name == countAggregate {
+		return strconv.Itoa(len(values)), nil
+	}
+
+	if len(values) == 0 {
+		return "", nil
+	}
+
+	switch name {
+	case sumAggregate:
+		sum, err := sumValues(name, values)
+		if err != nil {
+			return "", err
+		}
+
+		return sum.String(), nil
+	case avgAggregate:
+		sum, err := sumValues(name, values)
+		if err != nil {
+			return "", err
+		}
+
+		// the average of exact numbers is exact, as the sum of integers is divided as a decimal
+		if sum.kind != floatValue {
+			return formatDecimal(new(big.Rat).Quo(toDecimal(sum), new(big.Rat).SetInt64(int64(len(values))))), nil
+		}
+
+		return strconv.FormatFloat(sum.float/float64(len(values)), 'f', -1, 64), nil
+	case minAggregate, maxAggregate:
+		return extremeValue(name, values), nil
+	}
+
+	return "", fmt.Errorf("aggregate function '%s' does not supported", name)
+}
+
+// sumValues adds the values as numbers of the widest kind among them.
+func sumValues(name aggregateFunction, values []string) (value, error) {
+	nums, err := parseNumbers(name, values)
+	if err != nil {
+		return value{}, err
+	}
+
+	plus := parsing.Token{Value: string(parsing.PlusOperation), Kind: parsing.OperationKind}
+
+	sum := value{kind: intValue}
+	for _, num := range nums {
+		sum, err = calculate(sum, plus, num)
+		if err != nil {
+			return value{}, err
+		}
+	}
+
+	return sum, nil
+}
+
+func parseNumbers(name aggregateFunction, values []string) ([]value, error) {
+	nums := make([]value, 0, len(values))
+	for _, val := range values {
+		num, ok := parseNumber(val)
+		if !ok {
+			return nums, fmt.Errorf("types do not match. %s works only with numbers, got %s", name, val)
+		}
+
+		nums = append(nums, num)
+	}
+
+	return nums, nil
+}
+
+// extremeValue returns the smallest or the largest value, numbers are compared
+// numerically when all values are numbers.
+func extremeValue(name aggregateFunction, values []string) string {
+	nums, err := parseNumbers(name, values)
+	numeric := err == nil
+
+	result := 0
+	for idx := 1; idx < len(values); idx++ {
+		var cmp int
+		if numeric {
+			cmp = compareNumbers(nums[idx], nums[result])
+		} else {
+			cmp = strings.Compare(strings.ToLower(values[idx]), strings.ToLower(values[result]))
+		}
+
+		if (name == minAggregate && cmp < 0) || (name == maxAggregate && cmp > 0) {
+			result = idx
+		}
+	}
+
+	return values[result]
+}
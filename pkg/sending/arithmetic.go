@@ -0,0 +1,175 @@
+package sending
+
+import (
+	"course_project/pkg/parsing"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+func isArithmetic(op parsing.Token) bool {
+	if op.Kind == parsing.SymbolKind {
+		return op.Value == string(parsing.MultiplyOperation)
+	}
+
+	switch parsing.Operation(op.Value) {
+	case parsing.PlusOperation, parsing.MinusOperation, parsing.DivideOperation, parsing.ModuloOperation:
+		return op.Kind == parsing.OperationKind
+	}
+
+	return false
+}
+
+func isNumber(v value) bool {
+	return v.kind == intValue || v.kind == decimalValue || v.kind == floatValue
+}
+
+// toNumber converts csv cells to int, decimal or float value.
+func toNumber(v value) (value, error) {
+	switch v.kind {
+	case intValue, decimalValue, floatValue:
+		return v, nil
+	case stringValue:
+		if num, ok := parseNumber(v.str); ok {
+			return num, nil
+		}
+
+		return value{}, fmt.Errorf("types do not match. data %s must be a number", v.str)
+	case dateValue, timestampValue:
+		return value{}, fmt.Errorf("types do not match. date %s cannot be used as a number", v)
+	}
+
+	return value{}, fmt.Errorf("types do not match. condition cannot be used as a number")
+}
+
+// calculate applies an arithmetic operation to numbers promoted to the same kind.
+// As in postgres 7 / 2 is 3 for integers, decimals are exact and an integer
+// which does not fit int64 becomes a decimal.
+func calculate(left value, op parsing.Token, right value) (value, error) {
+	l, err := toNumber(left)
+	if err != nil {
+		return value{}, err
+	}
+
+	r, err := toNumber(right)
+	if err != nil {
+		return value{}, err
+	}
+	l, r = promote(l, r)
+
+	operation := parsing.Operation(op.Value)
+
+	isDivision := operation == parsing.DivideOperation || operation == parsing.ModuloOperation
+	if isDivision && isZero(r) {
+		return value{}, fmt.Errorf("division by zero")
+	}
+
+	switch l.kind {
+	case intValue:
+		if num, ok := calculateInts(l.num, operation, r.num); ok {
+			return value{kind: intValue, num: num}, nil
+		}
+
+		if operation == parsing.DivideOperation {
+			// only MinInt64 / -1 overflows
+			return value{kind: decimalValue, decimal: new(big.Rat).Neg(toDecimal(l))}, nil
+		}
+
+		return calculateDecimals(toDecimal(l), operation, toDecimal(r))
+	case decimalValue:
+		return calculateDecimals(l.decimal, operation, r.decimal)
+	}
+
+	a, b := l.float, r.float
+	switch operation {
+	case parsing.PlusOperation:
+		return value{kind: floatValue, float: a + b}, nil
+	case parsing.MinusOperation:
+		return value{kind: floatValue, float: a - b}, nil
+	case parsing.MultiplyOperation:
+		return value{kind: floatValue, float: a * b}, nil
+	case parsing.DivideOperation:
+		return value{kind: floatValue, float: a / b}, nil
+	case parsing.ModuloOperation:
+		return value{kind: floatValue, float: math.Mod(a, b)}, nil
+	}
+
+	return value{}, fmt.Errorf("operation '%s' does not supported", op.Value)
+}
+
+// calculateInts returns false when the result does not fit int64.
+func calculateInts(a int64, operation parsing.Operation, b int64) (int64, bool) {
+	switch operation {
+	case parsing.PlusOperation:
+		sum := a + b
+		return sum, (sum > a) == (b > 0)
+	case parsing.MinusOperation:
+		diff := a - b
+		return diff, (diff < a) == (b > 0)
+	case parsing.MultiplyOperation:
+		if a == 0 || b == 0 {
+			return 0, true
+		}
+
+		product := a * b
+		return product, product/b == a && !(a == -1 && b == math.MinInt64) && !(b == -1 && a == math.MinInt64)
+	case parsing.DivideOperation:
+		return a / b, !(a == math.MinInt64 && b == -1)
+	case parsing.ModuloOperation:
+		if b == -1 {
+			return 0, true
+		}
+
+		return a % b, true
+	}
+
+	return 0, false
+}
+
+func calculateDecimals(a *big.Rat, operation parsing.Operation, b *big.Rat) (value, error) {
+	result := new(big.Rat)
+
+	switch operation {
+	case parsing.PlusOperation:
+		result.Add(a, b)
+	case parsing.MinusOperation:
+		result.Sub(a, b)
+	case parsing.MultiplyOperation:
+		result.Mul(a, b)
+	case parsing.DivideOperation:
+		result.Quo(a, b)
+	case parsing.ModuloOperation:
+		// the remainder has the sign of the dividend, as for integers
+		quo := new(big.Rat).Quo(a, b)
+		trunc := new(big.Rat).SetInt(new(big.Int).Quo(quo.Num(), quo.Denom()))
+		result.Sub(a, trunc.Mul(trunc, b))
+	default:
+		return value{}, fmt.Errorf("operation '%s' does not supported", operation)
+	}
+
+	return value{kind: decimalValue, decimal: result}, nil
+}
+
+func negateNumber(operand value, op parsing.Token) (value, error) {
+	num, err := toNumber(operand)
+	if err != nil {
+		return value{}, err
+	}
+
+	if parsing.Operation(op.Value) == parsing.PlusOperation {
+		return num, nil
+	}
+
+	switch num.kind {
+	case intValue:
+		if num.num == math.MinInt64 {
+			return value{kind: decimalValue, decimal: new(big.Rat).Neg(toDecimal(num))}, nil
+		}
+
+		return value{kind: intValue, num: -num.num}, nil
+	case decimalValue:
+		return value{kind: decimalValue, decimal: new(big.Rat).Neg(num.decimal)}, nil
+	}
+
+	return value{kind: floatValue, float: -num.float}, nil
+}
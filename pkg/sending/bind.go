@@ -0,0 +1,374 @@
+package sending
+
+import (
+	"course_project/pkg/parsing"
+	"fmt"
+	"regexp"
+)
+
+// bindRequest checks function calls before any row is read: the functions must exist
+// and get the right number of arguments of the right types. It also compiles LIKE patterns,
+// regular expressions and IN lists.
+func (c *CsvParser) bindRequest(request *parsing.SelectStatement) error {
+	c.compiled = &compiled{
+		likePatterns: map[*parsing.LikeExpression]*likePattern{},
+		inSets:       map[*parsing.Expression]*inSet{},
+		regexps:      map[*parsing.Expression]*regexp.Regexp{},
+		subqueries:   map[*parsing.SelectStatement]*CsvModel{},
+	}
+
+	_, err := c.bindStatement(request)
+
+	return err
+}
+
+// bindStatement binds the statement and its subqueries, it returns types of the select list,
+// nil for SELECT *.
+func (c *CsvParser) bindStatement(request *parsing.SelectStatement) ([]valueType, error) {
+	if request.With != nil {
+		for _, table := range request.With.Tables {
+			if _, err := c.bindStatement(table.Select); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var itemTypes []valueType
+	if request.Compound != nil {
+		types, err := c.bindCompound(request.Compound)
+		if err != nil {
+			return nil, err
+		}
+
+		itemTypes = types
+	}
+
+	if request.FromSubquery != nil {
+		if _, err := c.bindStatement(request.FromSubquery); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, join := range request.Joins {
+		if join.Subquery != nil {
+			if _, err := c.bindStatement(join.Subquery); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	exps := append([]*parsing.Expression{}, request.Item...)
+	for _, join := range request.Joins {
+		if join.On != nil {
+			exps = append(exps, join.On)
+		}
+	}
+	if request.Where != nil {
+		exps = append(exps, request.Where)
+	}
+	exps = append(exps, request.DistinctOn...)
+	exps = append(exps, request.GroupBy...)
+	if request.Having != nil {
+		exps = append(exps, request.Having)
+	}
+	for _, item := range request.OrderBy {
+		exps = append(exps, item.Exp)
+	}
+
+	for idx, exp := range exps {
+		t, err := c.bindExpression(exp)
+		if err != nil {
+			return nil, err
+		}
+
+		if idx < len(request.Item) {
+			itemTypes = append(itemTypes, t)
+		}
+	}
+
+	return itemTypes, nil
+}
+
+// bindCompound checks that both sides of UNION and others have the same number of columns
+// of matching types, when it is known before the rows are read.
+func (c *CsvParser) bindCompound(compound *parsing.Compound) ([]valueType, error) {
+	left, err := c.bindStatement(compound.Left)
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := c.bindStatement(compound.Right)
+	if err != nil {
+		return nil, err
+	}
+
+	if left == nil || right == nil {
+		return nil, nil
+	}
+
+	if len(left) != len(right) {
+		return nil, fmt.Errorf("each %s query must have the same number of columns", setOperationName(compound))
+	}
+
+	types := make([]valueType, 0, len(left))
+	for idx := range left {
+		switch {
+		case left[idx] == right[idx] || right[idx] == anyType:
+			types = append(types, left[idx])
+		case left[idx] == anyType:
+			types = append(types, right[idx])
+		default:
+			return nil, fmt.Errorf("%s types %s and %s cannot be matched", setOperationName(compound), left[idx], right[idx])
+		}
+	}
+
+	return types, nil
+}
+
+// bindExpression returns the type of exp known without reading rows, anyType for columns.
+func (c *CsvParser) bindExpression(exp *parsing.Expression) (valueType, error) {
+	var argTypes []valueType
+	for _, child := range exp.Children() {
+		t, err := c.bindExpression(child)
+		if err != nil {
+			return anyType, err
+		}
+
+		argTypes = append(argTypes, t)
+	}
+
+	switch exp.Kind {
+	case parsing.LiteralKind:
+		switch exp.Literal.Kind {
+		case parsing.NumericKind:
+			return numberType, nil
+		case parsing.StringKind:
+			return stringType, nil
+		case parsing.DateKind, parsing.TimestampKind:
+			_, err := literalDate(exp.Literal.Value, exp.Literal.Kind == parsing.TimestampKind)
+			return dateType, err
+		}
+	case parsing.BinaryKind:
+		if isArithmetic(exp.Binary.Op) {
+			return numberType, nil
+		}
+
+		if parsing.Operation(exp.Binary.Op.Value) == parsing.ConcatOperation {
+			return stringType, nil
+		}
+
+		if isRegexp(exp.Binary.Op) {
+			for _, t := range argTypes {
+				if t == numberType || t == boolType {
+					return anyType, fmt.Errorf("types do not match. regular expressions work only with strings")
+				}
+			}
+
+			return boolType, c.bindRegexp(exp.Binary.B, isInsensitiveRegexp(exp.Binary.Op))
+		}
+
+		isIn := exp.Binary.Op.Kind == parsing.KeywordKind && parsing.Keyword(exp.Binary.Op.Value) == parsing.InKeyword
+		if isIn && exp.Binary.B.Kind == parsing.ListKind {
+			c.bindIn(exp.Binary.B)
+		}
+
+		return boolType, nil
+	case parsing.UnaryKind:
+		if exp.Unary.Op.Kind == parsing.OperationKind {
+			return numberType, nil
+		}
+
+		return boolType, nil
+	case parsing.BetweenKind:
+		return boolType, nil
+	case parsing.LikeKind:
+		for _, t := range argTypes {
+			if t == numberType || t == boolType {
+				return anyType, fmt.Errorf("types do not match. LIKE works only with strings")
+			}
+		}
+
+		return boolType, c.bindLike(exp.Like)
+	case parsing.CaseKind:
+		return bindCase(exp.Case, argTypes)
+	case parsing.SubqueryKind:
+		types, err := c.bindStatement(exp.Subquery)
+		if err != nil || len(types) != 1 {
+			return anyType, err
+		}
+
+		return types[0], nil
+	case parsing.ExistsKind:
+		_, err := c.bindStatement(exp.Subquery)
+
+		return boolType, err
+	case parsing.FunctionKind:
+		// children of a window are its arguments, then PARTITION BY and ORDER BY
+		argTypes = argTypes[:len(exp.Function.Args)]
+
+		if isWindow(exp) {
+			return bindWindow(exp.Function, argTypes)
+		}
+
+		if isAggregate(exp) {
+			return bindAggregate(exp.Function, argTypes)
+		}
+
+		if _, ok := windowFunctions[exp.Function.Name.Value]; ok {
+			return anyType, fmt.Errorf("window function '%s' requires an OVER clause", exp.Function.Name.Value)
+		}
+
+		t, err := bindFunction(exp.Function, argTypes)
+		if err != nil {
+			return anyType, err
+		}
+
+		if scalarFunctions[exp.Function.Name.Value].regexpCall != nil {
+			return t, c.bindRegexp(exp.Function.Args[1], false)
+		}
+
+		return t, bindDateFunction(exp.Function)
+	}
+
+	return anyType, nil
+}
+
+// bindCase checks that WHEN of a CASE without operand are conditions and returns
+// the type of the results when they all have the same one. argTypes are types of
+// the CASE children.
+func bindCase(caseExp *parsing.CaseExpression, argTypes []valueType) (valueType, error) {
+	if caseExp.Operand != nil {
+		argTypes = argTypes[1:]
+	}
+
+	var results []valueType
+	for idx, when := range caseExp.Whens {
+		if caseExp.Operand == nil && !isCompatible(boolType, argTypes[2*idx]) {
+			return anyType, fmt.Errorf("types do not match. WHEN '%s' must be a condition", when.When)
+		}
+
+		results = append(results, argTypes[2*idx+1])
+	}
+	if caseExp.Else != nil {
+		results = append(results, argTypes[len(argTypes)-1])
+	}
+
+	result := results[0]
+	for _, t := range results[1:] {
+		if t != result {
+			result = anyType
+		}
+	}
+
+	return result, nil
+}
+
+func bindAggregate(function *parsing.FunctionCall, argTypes []valueType) (valueType, error) {
+	name := aggregateFunction(function.Name.Value)
+
+	if function.IsAllArgs {
+		if name != countAggregate {
+			return anyType, fmt.Errorf("%s(*) does not supported", name)
+		}
+
+		return numberType, nil
+	}
+
+	if len(function.Args) != 1 {
+		return anyType, fmt.Errorf("aggregate function '%s' expects one argument", name)
+	}
+
+	switch name {
+	case countAggregate:
+		return numberType, nil
+	case sumAggregate, avgAggregate:
+		if !isCompatible(numberType, argTypes[0]) {
+			return anyType, fmt.Errorf("types do not match. %s works only with numbers, got %s", name, argTypes[0])
+		}
+
+		return numberType, nil
+	}
+
+	return argTypes[0], nil
+}
+
+func bindFunction(function *parsing.FunctionCall, argTypes []valueType) (valueType, error) {
+	name := function.Name.Value
+
+	f, ok := scalarFunctions[name]
+	if !ok {
+		return anyType, fmt.Errorf("function '%s' does not exist", name)
+	}
+
+	if function.IsAllArgs {
+		return anyType, fmt.Errorf("%s(*) does not supported", name)
+	}
+
+	if function.Distinct {
+		return anyType, fmt.Errorf("DISTINCT is specified, but '%s' is not an aggregate function", name)
+	}
+
+	if len(argTypes) < f.minArgs || (f.maxArgs != -1 && len(argTypes) > f.maxArgs) {
+		return anyType, fmt.Errorf("function '%s' expects %s, got %d", name, arityString(f), len(argTypes))
+	}
+
+	for idx, t := range argTypes {
+		expected := f.argTypes[len(f.argTypes)-1]
+		if idx < len(f.argTypes) {
+			expected = f.argTypes[idx]
+		}
+
+		if !isCompatible(expected, t) {
+			return anyType, fmt.Errorf("types do not match. argument %d of '%s' must be %s, got %s", idx+1, name, expected, t)
+		}
+	}
+
+	return f.result, nil
+}
+
+// bindDateFunction checks units and formats of date functions when they are constants.
+func bindDateFunction(function *parsing.FunctionCall) error {
+	var err error
+	switch name := function.Name.Value; name {
+	case "extract":
+		if isStringLiteral(function.Args[0]) {
+			_, err = extract([]value{{kind: stringValue, str: function.Args[0].Literal.Value}, {kind: dateValue}})
+		}
+	case "date_trunc", "date_add", "date_diff":
+		if isStringLiteral(function.Args[0]) {
+			_, err = toUnit(name, value{kind: stringValue, str: function.Args[0].Literal.Value})
+		}
+	case "to_date":
+		if isStringLiteral(function.Args[1]) {
+			_, err = parseDateFormat(function.Args[1].Literal.Value)
+		}
+	}
+
+	return err
+}
+
+// isCompatible reports whether a value of the actual type can be passed where the expected
+// one is needed. Numbers are converted to strings, column values are checked per row.
+func isCompatible(expected, actual valueType) bool {
+	switch {
+	case expected == anyType || actual == anyType || expected == actual:
+		return true
+	case expected == stringType:
+		return actual == numberType || actual == dateType
+	}
+
+	return false
+}
+
+func arityString(f scalarFunction) string {
+	switch {
+	case f.maxArgs == -1:
+		return fmt.Sprintf("at least %d arguments", f.minArgs)
+	case f.minArgs == f.maxArgs && f.minArgs == 1:
+		return "1 argument"
+	case f.minArgs == f.maxArgs:
+		return fmt.Sprintf("%d arguments", f.minArgs)
+	}
+
+	return fmt.Sprintf("%d to %d arguments", f.minArgs, f.maxArgs)
+}
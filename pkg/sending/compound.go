@@ -0,0 +1,109 @@
+package sending
+
+import (
+	"course_project/pkg/parsing"
+	"fmt"
+)
+
+func setOperationName(compound *parsing.Compound) string {
+	switch compound.Kind {
+	case parsing.Intersect:
+		return "INTERSECT"
+	case parsing.Except:
+		return "EXCEPT"
+	}
+
+	return "UNION"
+}
+
+// compoundQuery combines rows of both sides of UNION, INTERSECT or EXCEPT. Rows are equal
+// when all cells are equal, so NULL is equal to NULL as in postgres. Without ALL the result
+// has no equal rows, with ALL a row is kept as many times as it is in the sides.
+func (c *CsvParser) compoundQuery(request *parsing.SelectStatement) (*CsvModel, error) {
+	compound := request.Compound
+
+	left, err := c.query(compound.Left)
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := c.query(compound.Right)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(left.columnsName) != len(right.columnsName) {
+		return nil, fmt.Errorf("each %s query must have the same number of columns", setOperationName(compound))
+	}
+
+	result := &CsvModel{columnsName: left.columnsName, nullValues: left.nullValues}
+
+	switch compound.Kind {
+	case parsing.Union:
+		result.data = append(append([][]string{}, left.data...), right.data...)
+		if !compound.All {
+			result.data = distinctRows(result.data)
+		}
+	default:
+		result.data = combineRows(left.data, right.data, compound)
+	}
+
+	// ORDER BY and the limit of the statement refer to the columns of the result
+	combined := c.derive(result)
+	for _, item := range request.OrderBy {
+		if val, ok := combined.checkExpressionColumnName(item.Exp); !ok {
+			return nil, combined.columnError(val)
+		}
+	}
+
+	rows, err := combined.sortRows(result.data, request.OrderBy)
+	if err != nil {
+		return nil, err
+	}
+	result.data = limitRows(rows, request)
+
+	return result, nil
+}
+
+// combineRows returns rows of left which are in right for INTERSECT and which are not for EXCEPT.
+func combineRows(left, right [][]string, compound *parsing.Compound) [][]string {
+	rightRows := newRowSet()
+	var counts []int
+	for _, row := range right {
+		if idx := rightRows.index(row); idx != -1 {
+			counts[idx]++
+			continue
+		}
+
+		rightRows.add(row)
+		counts = append(counts, 1)
+	}
+
+	isIntersect := compound.Kind == parsing.Intersect
+	seen := newRowSet()
+
+	var rows [][]string
+	for _, row := range left {
+		idx := rightRows.index(row)
+
+		if compound.All {
+			// every row of right matches one row of left
+			isMatched := idx != -1 && counts[idx] > 0
+			if isMatched {
+				counts[idx]--
+			}
+
+			if isMatched == isIntersect {
+				rows = append(rows, row)
+			}
+
+			continue
+		}
+
+		if (idx != -1) == isIntersect && seen.add(row) {
+			rows = append(rows, row)
+		}
+	}
+
+	return rows
+}
@@ -1,51 +1,127 @@
 package sending
 
 import (
-	"regexp"
+	"sort"
 	"strings"
 )
 
 type CsvModel struct {
 	columnsName []string
 	data        [][]string
+	// computed maps text of expressions calculated before, like 'count(*)', to columns
+	computed map[string]int
+	// nullValues are cells read as NULL, only empty cells when it is nil
+	nullValues map[string]bool
+	// qualifiers are table names or aliases of the columns, so 'b.status' can be used
+	// in a query, nil for models which are not tables
+	qualifiers []string
+	// formats are input formats of date columns, nil when a table has none
+	formats []*dateFormat
 }
 
-func (c *CsvModel) FindColumnNameFromCsv(name string) bool {
-	for _, val := range c.columnsName {
-		if val == name {
-			return true
+// setDateColumns sets formats of the columns found in dateColumns.
+func (c *CsvModel) setDateColumns(dateColumns map[string]*dateFormat) {
+	for idx, name := range c.columnsName {
+		if format, ok := dateColumns[name]; ok {
+			if c.formats == nil {
+				c.formats = make([]*dateFormat, len(c.columnsName))
+			}
+
+			c.formats[idx] = format
 		}
 	}
+}
 
-	return false
+// qualified returns the model with all columns qualified with the table name or alias.
+func (c *CsvModel) qualified(qualifier string) *CsvModel {
+	qualifiers := make([]string, len(c.columnsName))
+	for idx := range qualifiers {
+		qualifiers[idx] = qualifier
+	}
+
+	return &CsvModel{columnsName: c.columnsName, data: c.data, nullValues: c.nullValues, qualifiers: qualifiers, formats: c.formats}
 }
 
-func (c *CsvModel) GetIdxColumnName(name string) int {
-	for idx := range c.columnsName {
-		if c.columnsName[idx] == name {
-			return idx
-		}
+// nullCell returns a cell which is read as NULL.
+func (c *CsvModel) nullCell() string {
+	if c.nullValues == nil || c.nullValues[""] {
+		return ""
+	}
+
+	cells := make([]string, 0, len(c.nullValues))
+	for cell := range c.nullValues {
+		cells = append(cells, cell)
 	}
+	sort.Strings(cells)
 
-	return -1
+	return cells[0]
 }
 
-func (c *CsvModel) checkOnInt() error {
-	for idx, str := range c.data {
-		for idxStr, val := range str {
-			ok, err := regexp.MatchString("\\d+[.]\\d+", val)
-			if err != nil {
-				return err
-			}
-			if ok {
-				values := strings.Split(val, ".")
+func (c *CsvModel) isNullCell(cell string) bool {
+	if c.nullValues == nil {
+		return cell == ""
+	}
+
+	return c.nullValues[cell]
+}
 
-				if values[1] == "0" {
-					c.data[idx][idxStr] = values[0]
-				}
+// cell returns the value of the column idx in the row.
+func (c *CsvModel) cell(row []string, idx int) value {
+	if c.isNullCell(row[idx]) {
+		return value{kind: nullValue}
+	}
+
+	// a cell which does not match the format of its column is read as a string
+	if c.formats != nil && c.formats[idx] != nil {
+		if date, ok := c.formats[idx].parse(row[idx]); ok {
+			return date
+		}
+	}
+
+	return value{kind: stringValue, str: row[idx]}
+}
+
+// findColumn returns the index of the first column with the name and the number of such columns.
+// The name may be qualified with a table name or alias.
+func (c *CsvModel) findColumn(name string) (int, int) {
+	found, count := -1, 0
+	for idx, val := range c.columnsName {
+		isQualified := c.qualifiers != nil && isQualifiedName(name, c.qualifiers[idx], val)
+		if val == name || isQualified {
+			if count == 0 {
+				found = idx
 			}
+			count++
 		}
 	}
 
-	return nil
+	return found, count
+}
+
+// isQualifiedName reports whether name is 'qualifier.column', without building the string.
+func isQualifiedName(name, qualifier, column string) bool {
+	return len(name) == len(qualifier)+1+len(column) &&
+		strings.HasPrefix(name, qualifier) && name[len(qualifier)] == '.' && strings.HasSuffix(name, column)
+}
+
+func (c *CsvModel) FindColumnNameFromCsv(name string) bool {
+	_, count := c.findColumn(name)
+
+	return count == 1
+}
+
+// isAmbiguous reports whether several columns, for example of joined tables, have the name.
+func (c *CsvModel) isAmbiguous(name string) bool {
+	_, count := c.findColumn(name)
+
+	return count > 1
+}
+
+func (c *CsvModel) GetIdxColumnName(name string) int {
+	idx, count := c.findColumn(name)
+	if count != 1 {
+		return -1
+	}
+
+	return idx
 }
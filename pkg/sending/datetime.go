@@ -0,0 +1,435 @@
+package sending
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	dateLayout      = "2006-01-02"
+	timestampLayout = "2006-01-02 15:04:05"
+)
+
+// timestampLayouts are accepted by TIMESTAMP literals and strings compared with dates,
+// fractional seconds are accepted after seconds by time.Parse.
+var timestampLayouts = []string{timestampLayout, "2006-01-02T15:04:05", "2006-01-02 15:04"}
+
+type dateUnit string
+
+const (
+	yearUnit    dateUnit = "year"
+	quarterUnit dateUnit = "quarter"
+	monthUnit   dateUnit = "month"
+	weekUnit    dateUnit = "week"
+	dayUnit     dateUnit = "day"
+	hourUnit    dateUnit = "hour"
+	minuteUnit  dateUnit = "minute"
+	secondUnit  dateUnit = "second"
+)
+
+// durations of units shorter than a day, longer ones depend on the calendar
+var unitDurations = map[dateUnit]time.Duration{
+	hourUnit:   time.Hour,
+	minuteUnit: time.Minute,
+	secondUnit: time.Second,
+}
+
+// maxYear is the last year of dates, the years of dates written with 'YYYY' are four digits.
+const maxYear = 9999
+
+// dateAddLimits are the largest amounts of units which fit in maxYear years, larger amounts
+// of DATE_ADD are out of range and are rejected before they can overflow.
+var dateAddLimits = map[dateUnit]int64{
+	yearUnit:    maxYear,
+	quarterUnit: maxYear * 4,
+	monthUnit:   maxYear * 12,
+	weekUnit:    maxYear * 53,
+	dayUnit:     maxYear * 366,
+	hourUnit:    maxYear * 366 * 24,
+	minuteUnit:  maxYear * 366 * 24 * 60,
+	secondUnit:  maxYear * 366 * 24 * 60 * 60,
+}
+
+// extractFields are fields of EXTRACT other than units
+var extractFields = map[string]bool{"dow": true, "doy": true, "epoch": true}
+
+func isDateUnit(unit string) bool {
+	switch dateUnit(unit) {
+	case yearUnit, quarterUnit, monthUnit, weekUnit, dayUnit, hourUnit, minuteUnit, secondUnit:
+		return true
+	}
+
+	return false
+}
+
+// dateFormat is a format of TO_DATE and of date columns in the config, like 'YYYY.MM',
+// translated to a layout of time.Parse.
+type dateFormat struct {
+	layout  string
+	hasTime bool
+}
+
+// dateFormatTokens are ordered so longer tokens are matched first
+var dateFormatTokens = []struct {
+	token   string
+	layout  string
+	hasTime bool
+}{
+	{token: "YYYY", layout: "2006"},
+	{token: "HH24", layout: "15", hasTime: true},
+	{token: "HH12", layout: "03", hasTime: true},
+	{token: "MON", layout: "Jan"},
+	{token: "YY", layout: "06"},
+	{token: "MM", layout: "01"},
+	{token: "DD", layout: "02"},
+	{token: "HH", layout: "03", hasTime: true},
+	{token: "MI", layout: "04", hasTime: true},
+	{token: "SS", layout: "05", hasTime: true},
+	{token: "AM", layout: "PM", hasTime: true},
+	{token: "PM", layout: "PM", hasTime: true},
+}
+
+func parseDateFormat(format string) (*dateFormat, error) {
+	result := &dateFormat{}
+
+	var layout strings.Builder
+	rest := strings.ToUpper(format)
+next:
+	for len(rest) != 0 {
+		for _, t := range dateFormatTokens {
+			if strings.HasPrefix(rest, t.token) {
+				layout.WriteString(t.layout)
+				result.hasTime = result.hasTime || t.hasTime
+				rest = rest[len(t.token):]
+
+				continue next
+			}
+		}
+
+		c := rest[0]
+		if (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
+			return nil, fmt.Errorf("invalid date format '%s', unknown field at '%s'", format, rest)
+		}
+
+		layout.WriteByte(c)
+		rest = rest[1:]
+	}
+
+	result.layout = layout.String()
+
+	return result, nil
+}
+
+// parse reads str in the format, the result is a date when the format has no time.
+func (f *dateFormat) parse(str string) (value, bool) {
+	t, err := time.Parse(f.layout, str)
+	if err != nil {
+		return value{}, false
+	}
+
+	if f.hasTime {
+		return value{kind: timestampValue, time: t, format: f}, true
+	}
+
+	return value{kind: dateValue, time: t, format: f}, true
+}
+
+func isDate(v value) bool {
+	return v.kind == dateValue || v.kind == timestampValue
+}
+
+func formatTime(v value) string {
+	if v.kind == dateValue {
+		return v.time.Format(dateLayout)
+	}
+
+	return v.time.Format(timestampLayout + ".999999")
+}
+
+// parseDate reads a date written as 2016-06-01 or a timestamp written as 2016-06-01 10:00:00.
+func parseDate(str string) (value, bool) {
+	if t, err := time.Parse(dateLayout, str); err == nil {
+		return value{kind: dateValue, time: t}, true
+	}
+
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, str); err == nil {
+			return value{kind: timestampValue, time: t}, true
+		}
+	}
+
+	return value{}, false
+}
+
+// toDate converts strings, like csv cells or dates written to a result, to date values.
+func toDate(v value) (value, error) {
+	switch v.kind {
+	case dateValue, timestampValue:
+		return v, nil
+	case stringValue:
+		if date, ok := parseDate(v.str); ok {
+			return date, nil
+		}
+
+		return value{}, fmt.Errorf("types do not match. data %s must be a date", v.str)
+	case intValue, decimalValue, floatValue:
+		return value{}, fmt.Errorf("types do not match. number %s cannot be used as a date", v)
+	}
+
+	return value{}, fmt.Errorf("types do not match. condition cannot be used as a date")
+}
+
+// toDateIn converts a string in the format, when it is given, or as toDate does.
+func toDateIn(v value, format *dateFormat) (value, error) {
+	if v.kind == stringValue && format != nil {
+		if date, ok := format.parse(v.str); ok {
+			return date, nil
+		}
+	}
+
+	return toDate(v)
+}
+
+// literalDate checks DATE and TIMESTAMP literals, a timestamp may be written without time.
+func literalDate(str string, isTimestamp bool) (value, error) {
+	date, ok := parseDate(str)
+	switch {
+	case !ok && isTimestamp:
+		return value{}, fmt.Errorf("invalid input syntax for type timestamp: '%s'", str)
+	case !ok || (!isTimestamp && date.kind != dateValue):
+		return value{}, fmt.Errorf("invalid input syntax for type date: '%s'", str)
+	case isTimestamp:
+		date.kind = timestampValue
+	}
+
+	return date, nil
+}
+
+func compareTimes(l, r time.Time) int {
+	switch {
+	case l.Before(r):
+		return -1
+	case l.After(r):
+		return 1
+	}
+
+	return 0
+}
+
+func toUnit(name string, v value) (dateUnit, error) {
+	unit := strings.ToLower(v.String())
+	if !isDateUnit(unit) {
+		return "", fmt.Errorf("%s: unit '%s' is not recognized", name, unit)
+	}
+
+	return dateUnit(unit), nil
+}
+
+// truncateTime returns the start of the unit, weeks start on Monday.
+func truncateTime(t time.Time, unit dateUnit) time.Time {
+	year, month, day := t.Date()
+
+	switch unit {
+	case yearUnit:
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	case quarterUnit:
+		return time.Date(year, month-(month-1)%3, 1, 0, 0, 0, 0, time.UTC)
+	case monthUnit:
+		return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	case weekUnit:
+		return time.Date(year, month, day-(int(t.Weekday())+6)%7, 0, 0, 0, 0, time.UTC)
+	case dayUnit:
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	return t.Truncate(unitDurations[unit])
+}
+
+// addMonths adds months keeping the day, the last day of a shorter month is used
+// instead of a missing one: 2016-01-31 plus one month is 2016-02-29.
+func addMonths(t time.Time, months int) time.Time {
+	year, month, day := t.Date()
+	first := time.Date(year, month+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+
+	return first.AddDate(0, 0, day-1)
+}
+
+// dateKind is the kind of a date function result: units shorter than a day make a timestamp.
+func dateKind(v value, unit dateUnit) valueKind {
+	if _, ok := unitDurations[unit]; ok {
+		return timestampValue
+	}
+
+	return v.kind
+}
+
+func extract(args []value) (value, error) {
+	field := strings.ToLower(args[0].String())
+	if !isDateUnit(field) && !extractFields[field] {
+		return value{}, fmt.Errorf("extract: field '%s' is not recognized", field)
+	}
+
+	date, err := toDate(args[1])
+	if err != nil {
+		return value{}, err
+	}
+	t := date.time
+
+	var num int
+	switch field {
+	case string(yearUnit):
+		num = t.Year()
+	case string(quarterUnit):
+		num = (int(t.Month())-1)/3 + 1
+	case string(monthUnit):
+		num = int(t.Month())
+	case string(weekUnit):
+		_, num = t.ISOWeek()
+	case string(dayUnit):
+		num = t.Day()
+	case string(hourUnit):
+		num = t.Hour()
+	case string(minuteUnit):
+		num = t.Minute()
+	case string(secondUnit):
+		num = t.Second()
+	case "dow":
+		num = int(t.Weekday())
+	case "doy":
+		num = t.YearDay()
+	case "epoch":
+		return value{kind: intValue, num: t.Unix()}, nil
+	}
+
+	return value{kind: intValue, num: int64(num)}, nil
+}
+
+func dateTrunc(args []value) (value, error) {
+	unit, err := toUnit("date_trunc", args[0])
+	if err != nil {
+		return value{}, err
+	}
+
+	date, err := toDate(args[1])
+	if err != nil {
+		return value{}, err
+	}
+
+	return value{kind: date.kind, time: truncateTime(date.time, unit)}, nil
+}
+
+func dateAdd(args []value) (value, error) {
+	unit, err := toUnit("date_add", args[0])
+	if err != nil {
+		return value{}, err
+	}
+
+	amount, err := toNumber(args[1])
+	if err != nil {
+		return value{}, err
+	}
+
+	if amount.kind != intValue {
+		return value{}, fmt.Errorf("date_add expects integer amount, got %s", amount)
+	}
+
+	if amount.num > dateAddLimits[unit] || amount.num < -dateAddLimits[unit] {
+		return value{}, fmt.Errorf("date_add: date out of range, %s %s", amount, unit)
+	}
+	n := int(amount.num)
+
+	date, err := toDate(args[2])
+	if err != nil {
+		return value{}, err
+	}
+	t := date.time
+
+	switch unit {
+	case yearUnit:
+		t = addMonths(t, 12*n)
+	case quarterUnit:
+		t = addMonths(t, 3*n)
+	case monthUnit:
+		t = addMonths(t, n)
+	case weekUnit:
+		t = t.AddDate(0, 0, 7*n)
+	case dayUnit:
+		t = t.AddDate(0, 0, n)
+	default:
+		// whole days are added apart, as a duration of thousands of years overflows
+		perDay := int(24 * time.Hour / unitDurations[unit])
+		t = t.AddDate(0, 0, n/perDay).Add(time.Duration(n%perDay) * unitDurations[unit])
+	}
+
+	if t.Year() < 1 || t.Year() > maxYear {
+		return value{}, fmt.Errorf("date_add: date out of range, %s %s", amount, unit)
+	}
+
+	return value{kind: dateKind(date, unit), time: t}, nil
+}
+
+// dateDiff returns the number of unit boundaries between start and end,
+// so from 2016-06-30 to 2016-07-01 is one month.
+func dateDiff(args []value) (value, error) {
+	unit, err := toUnit("date_diff", args[0])
+	if err != nil {
+		return value{}, err
+	}
+
+	start, err := toDate(args[1])
+	if err != nil {
+		return value{}, err
+	}
+
+	end, err := toDate(args[2])
+	if err != nil {
+		return value{}, err
+	}
+
+	from, to := truncateTime(start.time, unit), truncateTime(end.time, unit)
+
+	var diff int64
+	switch unit {
+	case yearUnit:
+		diff = int64(to.Year() - from.Year())
+	case quarterUnit, monthUnit:
+		diff = int64((to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month()))
+		if unit == quarterUnit {
+			diff /= 3
+		}
+	case weekUnit:
+		diff = int64(to.Sub(from) / (7 * 24 * time.Hour))
+	case dayUnit:
+		diff = int64(to.Sub(from) / (24 * time.Hour))
+	default:
+		diff = int64(to.Sub(from) / unitDurations[unit])
+	}
+
+	return value{kind: intValue, num: diff}, nil
+}
+
+// now returns the current local time without the time zone, as timestamps of csv files have none.
+func now(args []value) (value, error) {
+	t := time.Now()
+
+	return value{kind: timestampValue, time: time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)}, nil
+}
+
+func toDateFunction(args []value) (value, error) {
+	format, err := parseDateFormat(args[1].String())
+	if err != nil {
+		return value{}, err
+	}
+
+	date, ok := format.parse(args[0].String())
+	if !ok {
+		return value{}, fmt.Errorf("to_date: '%s' does not match format '%s'", args[0], args[1])
+	}
+
+	return value{kind: dateValue, time: truncateTime(date.time, dayUnit)}, nil
+}
@@ -0,0 +1,108 @@
+package sending
+
+import (
+	"course_project/pkg/parsing"
+	"hash/fnv"
+	"strconv"
+)
+
+// rowSet keeps row hashes and indexes of the rows already seen, values
+// themselves are compared only when hashes collide.
+type rowSet struct {
+	buckets map[uint64][]int
+	rows    [][]string
+}
+
+func newRowSet() *rowSet {
+	return &rowSet{buckets: map[uint64][]int{}}
+}
+
+// add returns false when an equal row was added before.
+func (s *rowSet) add(row []string) bool {
+	if s.index(row) != -1 {
+		return false
+	}
+
+	sum := hashRow(row)
+	s.buckets[sum] = append(s.buckets[sum], len(s.rows))
+	s.rows = append(s.rows, row)
+
+	return true
+}
+
+// index returns the number of the equal row in the order of adding, -1 when there is no such row.
+func (s *rowSet) index(row []string) int {
+	for _, idx := range s.buckets[hashRow(row)] {
+		if equalRows(s.rows[idx], row) {
+			return idx
+		}
+	}
+
+	return -1
+}
+
+func hashRow(row []string) uint64 {
+	h := fnv.New64a()
+	for _, val := range row {
+		// length prefix keeps ("ab", "c") and ("a", "bc") apart
+		_, _ = h.Write([]byte(strconv.Itoa(len(val)) + ":" + val))
+	}
+
+	return h.Sum64()
+}
+
+func equalRows(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for idx := range a {
+		if a[idx] != b[idx] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func distinctRows(rows [][]string) [][]string {
+	set := newRowSet()
+
+	var result [][]string
+	for _, row := range rows {
+		if set.add(row) {
+			result = append(result, row)
+		}
+	}
+
+	return result
+}
+
+// distinctOnRows keeps the first row of every set of rows with equal DISTINCT ON values,
+// so with ORDER BY it is the first row in that order.
+func (c *CsvParser) distinctOnRows(rows [][]string, distinctOn []*parsing.Expression) ([][]string, error) {
+	if len(distinctOn) == 0 {
+		return rows, nil
+	}
+
+	set := newRowSet()
+
+	var result [][]string
+	for _, str := range rows {
+		key := make([]string, 0, len(distinctOn))
+		for _, exp := range distinctOn {
+			val, err := c.evaluate(exp, str)
+			if err != nil {
+				return result, err
+			}
+
+			key = append(key, val.String())
+		}
+
+		if set.add(key) {
+			result = append(result, str)
+		}
+	}
+
+	return result, nil
+}
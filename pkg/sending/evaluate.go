@@ -0,0 +1,483 @@
+package sending
+
+import (
+	"course_project/pkg/parsing"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type valueKind uint
+
+const (
+	stringValue valueKind = iota
+	intValue
+	decimalValue
+	floatValue
+	boolValue
+	nullValue
+	dateValue
+	timestampValue
+)
+
+type value struct {
+	kind    valueKind
+	str     string
+	num     int64
+	decimal *big.Rat
+	float   float64
+	boolean bool
+	// time of dates and timestamps, they have no time zone and are kept in UTC
+	time time.Time
+	// format of the date column the date is read from, strings compared with the date
+	// are read in it too, so period = '2016.06' works for a column in format 'YYYY.MM'
+	format *dateFormat
+}
+
+func (v value) String() string {
+	switch v.kind {
+	case intValue:
+		return strconv.FormatInt(v.num, 10)
+	case decimalValue:
+		return formatDecimal(v.decimal)
+	case floatValue:
+		return strconv.FormatFloat(v.float, 'f', -1, 64)
+	case boolValue:
+		return strconv.FormatBool(v.boolean)
+	case dateValue, timestampValue:
+		return formatTime(v)
+	}
+
+	return v.str
+}
+
+func isNull(v value) bool {
+	return v.kind == nullValue
+}
+
+func (c *CsvParser) evaluate(exp *parsing.Expression, row []string) (value, error) {
+	if c.csvModel.computed != nil && exp.Kind != parsing.LiteralKind {
+		if idx, ok := c.csvModel.computed[exp.String()]; ok {
+			return c.csvModel.cell(row, idx), nil
+		}
+	}
+
+	switch exp.Kind {
+	case parsing.LiteralKind:
+		return c.evaluateLiteral(exp.Literal, row)
+	case parsing.BinaryKind:
+		return c.evaluateBinary(exp.Binary, row)
+	case parsing.UnaryKind:
+		return c.evaluateUnary(exp.Unary, row)
+	case parsing.BetweenKind:
+		return c.evaluateBetween(exp.Between, row)
+	case parsing.LikeKind:
+		return c.evaluateLike(exp.Like, row)
+	case parsing.CaseKind:
+		return c.evaluateCase(exp.Case, row)
+	case parsing.SubqueryKind:
+		return c.evaluateSubquery(exp.Subquery, row)
+	case parsing.ExistsKind:
+		return c.evaluateExists(exp.Subquery, row)
+	case parsing.FunctionKind:
+		if isAggregate(exp) {
+			return value{}, fmt.Errorf("aggregate function '%s' is not allowed here", exp)
+		}
+
+		if isWindow(exp) {
+			return value{}, fmt.Errorf("window function '%s' is not allowed here", exp)
+		}
+
+		return c.evaluateFunction(exp.Function, row)
+	}
+
+	return value{}, fmt.Errorf("unsupported expression '%s'", exp)
+}
+
+func (c *CsvParser) evaluateLiteral(literal *parsing.Token, row []string) (value, error) {
+	switch literal.Kind {
+	case parsing.IdentifierKind:
+		idx := c.csvModel.GetIdxColumnName(literal.Value)
+		if idx == -1 {
+			if val, ok := c.outer.lookup(literal.Value); ok {
+				return val, nil
+			}
+
+			return value{}, fmt.Errorf("no such column name '%s' in csv", literal.Value)
+		}
+
+		return c.csvModel.cell(row, idx), nil
+	case parsing.NumericKind:
+		return toNumber(value{kind: stringValue, str: literal.Value})
+	case parsing.StringKind:
+		return value{kind: stringValue, str: literal.Value}, nil
+	case parsing.DateKind, parsing.TimestampKind:
+		return literalDate(literal.Value, literal.Kind == parsing.TimestampKind)
+	case parsing.KeywordKind:
+		if parsing.Keyword(literal.Value) == parsing.NullKeyword {
+			return value{kind: nullValue}, nil
+		}
+	}
+
+	return value{}, fmt.Errorf("unsupported literal '%s'", literal.Value)
+}
+
+func (c *CsvParser) evaluateBinary(binary *parsing.BinaryExpression, row []string) (value, error) {
+	if binary.Op.Kind == parsing.KeywordKind {
+		switch parsing.Keyword(binary.Op.Value) {
+		case parsing.InKeyword:
+			return c.evaluateIn(binary.A, binary.B, row)
+		case parsing.RegexpKeyword:
+			return c.evaluateRegexp(binary, row)
+		case parsing.IsKeyword:
+			val, err := c.evaluate(binary.A, row)
+			if err != nil {
+				return value{}, err
+			}
+
+			return value{kind: boolValue, boolean: isNull(val)}, nil
+		}
+
+		a, err := c.evaluatePredicate(binary.A, row)
+		if err != nil {
+			return value{}, err
+		}
+
+		// FALSE AND NULL is FALSE and TRUE OR NULL is TRUE, the right side is not needed
+		switch parsing.Keyword(binary.Op.Value) {
+		case parsing.AndKeyword:
+			if a.kind == boolValue && !a.boolean {
+				return a, nil
+			}
+		case parsing.OrKeyword:
+			if a.kind == boolValue && a.boolean {
+				return a, nil
+			}
+		default:
+			return value{}, fmt.Errorf("operation '%s' does not supported", binary.Op.Value)
+		}
+
+		b, err := c.evaluatePredicate(binary.B, row)
+		if err != nil {
+			return value{}, err
+		}
+
+		if parsing.Keyword(binary.Op.Value) == parsing.AndKeyword {
+			return and(a, b), nil
+		}
+
+		return or(a, b), nil
+	}
+
+	if isRegexp(binary.Op) {
+		return c.evaluateRegexp(binary, row)
+	}
+
+	left, err := c.evaluate(binary.A, row)
+	if err != nil {
+		return value{}, err
+	}
+
+	right, err := c.evaluate(binary.B, row)
+	if err != nil {
+		return value{}, err
+	}
+
+	if isNull(left) || isNull(right) {
+		return value{kind: nullValue}, nil
+	}
+
+	if isArithmetic(binary.Op) {
+		return calculate(left, binary.Op, right)
+	}
+
+	if parsing.Operation(binary.Op.Value) == parsing.ConcatOperation {
+		return concat([]value{left, right})
+	}
+
+	ok, err := isConditionOperation(left, binary.Op, right)
+	if err != nil {
+		return value{}, err
+	}
+
+	return value{kind: boolValue, boolean: ok}, nil
+}
+
+func (c *CsvParser) evaluateUnary(unary *parsing.UnaryExpression, row []string) (value, error) {
+	if unary.Op.Kind == parsing.OperationKind {
+		operand, err := c.evaluate(unary.Operand, row)
+		if err != nil {
+			return value{}, err
+		}
+
+		if isNull(operand) {
+			return operand, nil
+		}
+
+		return negateNumber(operand, unary.Op)
+	}
+
+	if parsing.Keyword(unary.Op.Value) != parsing.NotKeyword {
+		return value{}, fmt.Errorf("operation '%s' does not supported", unary.Op.Value)
+	}
+
+	val, err := c.evaluatePredicate(unary.Operand, row)
+	if err != nil {
+		return value{}, err
+	}
+
+	if isNull(val) {
+		return val, nil
+	}
+
+	return value{kind: boolValue, boolean: !val.boolean}, nil
+}
+
+func (c *CsvParser) evaluateBetween(between *parsing.BetweenExpression, row []string) (value, error) {
+	val, err := c.evaluate(between.A, row)
+	if err != nil {
+		return value{}, err
+	}
+
+	low, err := c.evaluate(between.Low, row)
+	if err != nil {
+		return value{}, err
+	}
+
+	high, err := c.evaluate(between.High, row)
+	if err != nil {
+		return value{}, err
+	}
+
+	if isNull(val) {
+		return val, nil
+	}
+
+	// 'a BETWEEN low AND high' is 'a >= low AND a <= high', a NULL bound makes its part NULL
+	checks := []value{{kind: nullValue}, {kind: nullValue}}
+	for idx, bound := range []value{low, high} {
+		if isNull(bound) {
+			continue
+		}
+
+		cmp, err := compareValues(val, bound)
+		if err != nil {
+			return value{}, err
+		}
+
+		checks[idx] = value{kind: boolValue, boolean: (idx == 0 && cmp >= 0) || (idx == 1 && cmp <= 0)}
+	}
+
+	return and(checks[0], checks[1]), nil
+}
+
+// evaluateCase evaluates only the result of the first matched WHEN, without ELSE
+// the result is NULL when nothing matches.
+func (c *CsvParser) evaluateCase(caseExp *parsing.CaseExpression, row []string) (value, error) {
+	var operand value
+	if caseExp.Operand != nil {
+		var err error
+		operand, err = c.evaluate(caseExp.Operand, row)
+		if err != nil {
+			return value{}, err
+		}
+	}
+
+	for _, when := range caseExp.Whens {
+		var matched bool
+		if caseExp.Operand == nil {
+			ok, err := c.evaluateCondition(when.When, row)
+			if err != nil {
+				return value{}, err
+			}
+
+			matched = ok
+		} else {
+			val, err := c.evaluate(when.When, row)
+			if err != nil {
+				return value{}, err
+			}
+
+			if !isNull(operand) && !isNull(val) {
+				cmp, err := compareValues(operand, val)
+				if err != nil {
+					return value{}, err
+				}
+
+				matched = cmp == 0
+			}
+		}
+
+		if matched {
+			return c.evaluate(when.Result, row)
+		}
+	}
+
+	if caseExp.Else != nil {
+		return c.evaluate(caseExp.Else, row)
+	}
+
+	return value{kind: nullValue}, nil
+}
+
+// evaluateCondition reports whether exp is TRUE, NULL is treated as FALSE as WHERE does.
+func (c *CsvParser) evaluateCondition(exp *parsing.Expression, row []string) (bool, error) {
+	val, err := c.evaluatePredicate(exp, row)
+	if err != nil {
+		return false, err
+	}
+
+	return val.kind == boolValue && val.boolean, nil
+}
+
+// evaluatePredicate returns TRUE, FALSE or NULL.
+func (c *CsvParser) evaluatePredicate(exp *parsing.Expression, row []string) (value, error) {
+	val, err := c.evaluate(exp, row)
+	if err != nil {
+		return value{}, err
+	}
+
+	if val.kind != boolValue && val.kind != nullValue {
+		return value{}, fmt.Errorf("expression '%s' is not a condition", exp)
+	}
+
+	return val, nil
+}
+
+// and and or follow three-valued logic, where NULL is an unknown TRUE or FALSE.
+func and(a, b value) value {
+	if (a.kind == boolValue && !a.boolean) || (b.kind == boolValue && !b.boolean) {
+		return value{kind: boolValue, boolean: false}
+	}
+
+	if isNull(a) || isNull(b) {
+		return value{kind: nullValue}
+	}
+
+	return value{kind: boolValue, boolean: true}
+}
+
+func or(a, b value) value {
+	if (a.kind == boolValue && a.boolean) || (b.kind == boolValue && b.boolean) {
+		return value{kind: boolValue, boolean: true}
+	}
+
+	if isNull(a) || isNull(b) {
+		return value{kind: nullValue}
+	}
+
+	return value{kind: boolValue, boolean: false}
+}
+
+func isConditionOperation(left value, operation parsing.Token, right value) (bool, error) {
+	cmp, err := compareValues(left, right)
+	if err != nil {
+		return false, err
+	}
+
+	switch parsing.Operation(operation.Value) {
+	case parsing.EqualsOperation:
+		return cmp == 0, nil
+	case parsing.LessEqualOperation:
+		return cmp <= 0, nil
+	case parsing.LessOperation:
+		return cmp < 0, nil
+	case parsing.MoreEqualOperation:
+		return cmp >= 0, nil
+	case parsing.MoreOperation:
+		return cmp > 0, nil
+	case parsing.NotEqualOperation:
+		return cmp != 0, nil
+	}
+
+	return false, fmt.Errorf("operation '%s' does not supported", operation.Value)
+}
+
+// compareValues returns -1, 0 or 1. Strings are compared case-insensitively,
+// a string compared with a number must hold a number and with a date a date.
+// Two strings which both hold numbers or dates, like cells of two columns,
+// are compared as numbers or dates, as ORDER BY does.
+func compareValues(left, right value) (int, error) {
+	if left.kind == stringValue && right.kind == stringValue {
+		if l, ok := parseNumber(left.str); ok {
+			if r, ok := parseNumber(right.str); ok {
+				return compareNumbers(l, r), nil
+			}
+		}
+
+		if l, ok := parseDate(left.str); ok {
+			if r, ok := parseDate(right.str); ok {
+				return compareTimes(l.time, r.time), nil
+			}
+		}
+	}
+
+	if isDate(left) || isDate(right) {
+		l, err := toDateIn(left, right.format)
+		if err != nil {
+			return 0, err
+		}
+
+		r, err := toDateIn(right, left.format)
+		if err != nil {
+			return 0, err
+		}
+
+		return compareTimes(l.time, r.time), nil
+	}
+
+	if isNumber(left) || isNumber(right) {
+		l, err := toNumber(left)
+		if err != nil {
+			return 0, err
+		}
+
+		r, err := toNumber(right)
+		if err != nil {
+			return 0, err
+		}
+
+		return compareNumbers(l, r), nil
+	}
+
+	if left.kind == boolValue || right.kind == boolValue {
+		if left.kind != right.kind {
+			return 0, fmt.Errorf("types do not match. cannot compare condition with value")
+		}
+
+		if left.boolean == right.boolean {
+			return 0, nil
+		}
+		if right.boolean {
+			return -1, nil
+		}
+
+		return 1, nil
+	}
+
+	return strings.Compare(strings.ToLower(left.str), strings.ToLower(right.str)), nil
+}
+
+// compareFloats orders NaN above all numbers and equal to itself, as postgres does.
+func compareFloats(l, r float64) int {
+	switch {
+	case math.IsNaN(l) || math.IsNaN(r):
+		switch {
+		case math.IsNaN(l) && math.IsNaN(r):
+			return 0
+		case math.IsNaN(l):
+			return 1
+		}
+
+		return -1
+	case l < r:
+		return -1
+	case l > r:
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,377 @@
+package sending
+
+import (
+	"course_project/pkg/parsing"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+type valueType uint
+
+const (
+	anyType valueType = iota
+	stringType
+	numberType
+	boolType
+	dateType
+)
+
+func (t valueType) String() string {
+	switch t {
+	case stringType:
+		return "string"
+	case numberType:
+		return "number"
+	case boolType:
+		return "condition"
+	case dateType:
+		return "date"
+	}
+
+	return "any"
+}
+
+type scalarFunction struct {
+	minArgs int
+	// maxArgs is -1 for functions with any number of arguments
+	maxArgs int
+	// argTypes are checked at bind time, the last one is used for the rest of arguments
+	argTypes []valueType
+	result   valueType
+	// strict functions return NULL when any argument is NULL
+	strict bool
+	call   func(args []value) (value, error)
+	// lazyCall is used instead of call by functions which evaluate only some of their arguments
+	lazyCall func(args []argument) (value, error)
+	// regexpCall is used instead of call by functions with a regular expression as the second argument
+	regexpCall func(args []value, re *regexp.Regexp) (value, error)
+}
+
+// argument evaluates a function argument for the current row.
+type argument func() (value, error)
+
+var scalarFunctions = map[string]scalarFunction{
+	"upper": {
+		minArgs: 1, maxArgs: 1, argTypes: []valueType{stringType}, result: stringType, strict: true,
+		call: func(args []value) (value, error) {
+			return value{kind: stringValue, str: strings.ToUpper(args[0].String())}, nil
+		},
+	},
+	"lower": {
+		minArgs: 1, maxArgs: 1, argTypes: []valueType{stringType}, result: stringType, strict: true,
+		call: func(args []value) (value, error) {
+			return value{kind: stringValue, str: strings.ToLower(args[0].String())}, nil
+		},
+	},
+	"length": {
+		minArgs: 1, maxArgs: 1, argTypes: []valueType{stringType}, result: numberType, strict: true,
+		call: func(args []value) (value, error) {
+			return value{kind: intValue, num: int64(len([]rune(args[0].String())))}, nil
+		},
+	},
+	"substr": {
+		minArgs: 2, maxArgs: 3, argTypes: []valueType{stringType, numberType, numberType}, result: stringType, strict: true,
+		call: substr,
+	},
+	"trim": {
+		minArgs: 1, maxArgs: 1, argTypes: []valueType{stringType}, result: stringType, strict: true,
+		call: func(args []value) (value, error) {
+			return value{kind: stringValue, str: strings.TrimSpace(args[0].String())}, nil
+		},
+	},
+	"replace": {
+		minArgs: 3, maxArgs: 3, argTypes: []valueType{stringType}, result: stringType, strict: true,
+		call: func(args []value) (value, error) {
+			return value{kind: stringValue, str: strings.ReplaceAll(args[0].String(), args[1].String(), args[2].String())}, nil
+		},
+	},
+	"concat": {
+		minArgs: 1, maxArgs: -1, argTypes: []valueType{anyType}, result: stringType,
+		call: concat,
+	},
+	"round": {
+		minArgs: 1, maxArgs: 2, argTypes: []valueType{numberType, numberType}, result: numberType, strict: true,
+		call: round,
+	},
+	"abs": {
+		minArgs: 1, maxArgs: 1, argTypes: []valueType{numberType}, result: numberType, strict: true,
+		call: func(args []value) (value, error) {
+			return mathFunction(args[0], math.Abs, func(num *big.Rat) *big.Rat {
+				return new(big.Rat).Abs(num)
+			})
+		},
+	},
+	"floor": {
+		minArgs: 1, maxArgs: 1, argTypes: []valueType{numberType}, result: numberType, strict: true,
+		call: func(args []value) (value, error) {
+			return mathFunction(args[0], math.Floor, floorDecimal)
+		},
+	},
+	"ceil": {
+		minArgs: 1, maxArgs: 1, argTypes: []valueType{numberType}, result: numberType, strict: true,
+		call: func(args []value) (value, error) {
+			return mathFunction(args[0], math.Ceil, ceilDecimal)
+		},
+	},
+	"coalesce": {
+		minArgs: 1, maxArgs: -1, argTypes: []valueType{anyType}, result: anyType,
+		lazyCall: coalesce,
+	},
+	"nullif": {
+		minArgs: 2, maxArgs: 2, argTypes: []valueType{anyType}, result: anyType,
+		call: nullif,
+	},
+	"regexp_replace": {
+		minArgs: 3, maxArgs: 3, argTypes: []valueType{stringType}, result: stringType, strict: true,
+		regexpCall: regexpReplace,
+	},
+	"regexp_extract": {
+		minArgs: 2, maxArgs: 3, argTypes: []valueType{stringType, stringType, numberType}, result: stringType, strict: true,
+		regexpCall: regexpExtract,
+	},
+	"iif": {
+		minArgs: 3, maxArgs: 3, argTypes: []valueType{boolType, anyType}, result: anyType,
+		lazyCall: iif,
+	},
+	"extract": {
+		minArgs: 2, maxArgs: 2, argTypes: []valueType{stringType, dateType}, result: numberType, strict: true,
+		call: extract,
+	},
+	"date_trunc": {
+		minArgs: 2, maxArgs: 2, argTypes: []valueType{stringType, dateType}, result: dateType, strict: true,
+		call: dateTrunc,
+	},
+	"date_add": {
+		minArgs: 3, maxArgs: 3, argTypes: []valueType{stringType, numberType, dateType}, result: dateType, strict: true,
+		call: dateAdd,
+	},
+	"date_diff": {
+		minArgs: 3, maxArgs: 3, argTypes: []valueType{stringType, dateType, dateType}, result: numberType, strict: true,
+		call: dateDiff,
+	},
+	"now": {
+		minArgs: 0, maxArgs: 0, result: dateType,
+		call: now,
+	},
+	"to_date": {
+		minArgs: 2, maxArgs: 2, argTypes: []valueType{stringType, stringType}, result: dateType, strict: true,
+		call: toDateFunction,
+	},
+}
+
+func (c *CsvParser) evaluateFunction(function *parsing.FunctionCall, row []string) (value, error) {
+	f, ok := scalarFunctions[function.Name.Value]
+	if !ok {
+		return value{}, fmt.Errorf("function '%s' does not exist", function.Name.Value)
+	}
+
+	if f.lazyCall != nil {
+		args := make([]argument, 0, len(function.Args))
+		for _, arg := range function.Args {
+			arg := arg
+			args = append(args, func() (value, error) {
+				return c.evaluate(arg, row)
+			})
+		}
+
+		return f.lazyCall(args)
+	}
+
+	args := make([]value, 0, len(function.Args))
+	for _, arg := range function.Args {
+		val, err := c.evaluate(arg, row)
+		if err != nil {
+			return value{}, err
+		}
+
+		if f.strict && isNull(val) {
+			return value{kind: nullValue}, nil
+		}
+
+		args = append(args, val)
+	}
+
+	if f.regexpCall != nil {
+		re, err := c.regexpFor(function.Args[1], args[1], false)
+		if err != nil {
+			return value{}, err
+		}
+
+		return f.regexpCall(args, re)
+	}
+
+	return f.call(args)
+}
+
+func substr(args []value) (value, error) {
+	str := []rune(args[0].String())
+
+	start, err := toNumber(args[1])
+	if err != nil {
+		return value{}, err
+	}
+
+	// positions start from 1, as in SQL; bounds are counted as floats,
+	// so huge positions and lengths do not overflow int
+	from := math.Trunc(toFloat(start)) - 1
+	to := float64(len(str))
+
+	if len(args) == 3 {
+		length, err := toNumber(args[2])
+		if err != nil {
+			return value{}, err
+		}
+
+		if toFloat(length) < 0 {
+			return value{}, fmt.Errorf("negative substring length not allowed")
+		}
+
+		to = from + math.Trunc(toFloat(length))
+	}
+
+	begin, end := clampIndex(from, len(str)), clampIndex(to, len(str))
+	if begin > end {
+		begin = end
+	}
+
+	return value{kind: stringValue, str: string(str[begin:end])}, nil
+}
+
+// clampIndex converts a position to an index in [0, size], NaN is 0.
+func clampIndex(pos float64, size int) int {
+	switch {
+	case !(pos > 0):
+		return 0
+	case pos > float64(size):
+		return size
+	}
+
+	return int(pos)
+}
+
+func concat(args []value) (value, error) {
+	var b strings.Builder
+	for _, arg := range args {
+		b.WriteString(arg.String())
+	}
+
+	return value{kind: stringValue, str: b.String()}, nil
+}
+
+func round(args []value) (value, error) {
+	num, err := toNumber(args[0])
+	if err != nil {
+		return value{}, err
+	}
+
+	digits := 0
+	if len(args) == 2 {
+		d, err := toNumber(args[1])
+		if err != nil {
+			return value{}, err
+		}
+
+		if d.kind != intValue {
+			return value{}, fmt.Errorf("round expects integer number of digits, got %s", d)
+		}
+
+		if d.num > maxRoundDigits || d.num < -maxRoundDigits {
+			return value{}, fmt.Errorf("round: number of digits must be between %d and %d, got %s", -maxRoundDigits, maxRoundDigits, d)
+		}
+
+		digits = int(d.num)
+	}
+
+	switch num.kind {
+	case intValue:
+		if digits >= 0 {
+			return num, nil
+		}
+
+		return integerValue(roundDecimal(toDecimal(num), digits)), nil
+	case decimalValue:
+		return value{kind: decimalValue, decimal: roundDecimal(num.decimal, digits)}, nil
+	}
+
+	shift := math.Pow(10, float64(digits))
+	switch {
+	case shift == 0:
+		// all digits are rounded off
+		return value{kind: floatValue, float: 0}, nil
+	case math.IsInf(num.float*shift, 0):
+		// a float has no digits that far after the point
+		return num, nil
+	}
+
+	return value{kind: floatValue, float: math.Round(num.float*shift) / shift}, nil
+}
+
+// mathFunction applies floatFunc to float numbers and decimalFunc to exact ones,
+// integers stay integers.
+func mathFunction(arg value, floatFunc func(float64) float64, decimalFunc func(*big.Rat) *big.Rat) (value, error) {
+	num, err := toNumber(arg)
+	if err != nil {
+		return value{}, err
+	}
+
+	switch num.kind {
+	case intValue:
+		return integerValue(decimalFunc(toDecimal(num))), nil
+	case decimalValue:
+		return value{kind: decimalValue, decimal: decimalFunc(num.decimal)}, nil
+	}
+
+	return value{kind: floatValue, float: floatFunc(num.float)}, nil
+}
+
+func coalesce(args []argument) (value, error) {
+	for _, arg := range args {
+		val, err := arg()
+		if err != nil {
+			return value{}, err
+		}
+
+		if !isNull(val) {
+			return val, nil
+		}
+	}
+
+	return value{kind: nullValue}, nil
+}
+
+func nullif(args []value) (value, error) {
+	if isNull(args[0]) || isNull(args[1]) {
+		return args[0], nil
+	}
+
+	cmp, err := compareValues(args[0], args[1])
+	if err != nil {
+		return value{}, err
+	}
+
+	if cmp == 0 {
+		return value{kind: nullValue}, nil
+	}
+
+	return args[0], nil
+}
+
+func iif(args []argument) (value, error) {
+	condition, err := args[0]()
+	if err != nil {
+		return value{}, err
+	}
+
+	if condition.kind != boolValue && condition.kind != nullValue {
+		return value{}, fmt.Errorf("first argument of 'iif' is not a condition")
+	}
+
+	if condition.kind == boolValue && condition.boolean {
+		return args[1]()
+	}
+
+	return args[2]()
+}
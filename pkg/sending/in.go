@@ -0,0 +1,181 @@
+package sending
+
+import (
+	"course_project/pkg/parsing"
+	"math"
+	"strings"
+	"time"
+)
+
+// inSet holds the values of an IN list made of constants, either strings or numbers,
+// so that a row is checked with one lookup instead of comparing it with every item.
+type inSet struct {
+	// keys are made by hashKey, so 5, 5.0 and '5e0' are one item, as '=' finds them equal
+	keys map[string]bool
+	// numeric is set for a list of numbers, a value looked up in it must be a number
+	numeric bool
+	// hasNull is set for results of subqueries with NULL, then a missing value gives NULL
+	hasNull bool
+}
+
+// bindIn builds a set for the IN list when its items do not depend on the row,
+// other lists are compared item by item.
+func (c *CsvParser) bindIn(list *parsing.Expression) {
+	set := &inSet{keys: map[string]bool{}}
+	for idx, item := range list.List {
+		if !isConstant(item) {
+			return
+		}
+
+		val, err := c.evaluate(item, nil)
+		if err != nil {
+			return
+		}
+
+		switch {
+		case val.kind == stringValue:
+		case isNumber(val):
+			if _, ok := numberKey(val); !ok {
+				return
+			}
+		default:
+			return
+		}
+
+		// strings and numbers in one list are compared item by item
+		if idx == 0 {
+			set.numeric = isNumber(val)
+		} else if set.numeric != isNumber(val) {
+			return
+		}
+
+		set.keys[hashKey(val)] = true
+	}
+
+	c.compiled.inSets[list] = set
+}
+
+func isConstant(exp *parsing.Expression) bool {
+	switch exp.Kind {
+	case parsing.LiteralKind:
+		return exp.Literal.Kind != parsing.IdentifierKind
+	case parsing.SubqueryKind, parsing.ExistsKind:
+		return false
+	case parsing.FunctionKind:
+		if isAggregate(exp) || isWindow(exp) {
+			return false
+		}
+	}
+
+	for _, child := range exp.Children() {
+		if !isConstant(child) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// accepts reports whether val can be looked up in the set, a number or a date is compared
+// with a list of strings by converting each item.
+func (s *inSet) accepts(val value) bool {
+	return s.numeric || val.kind == stringValue
+}
+
+func (s *inSet) contains(val value) (bool, error) {
+	if s.numeric {
+		num, err := toNumber(val)
+		if err != nil {
+			return false, err
+		}
+		val = num
+	}
+
+	return s.keys[hashKey(val)], nil
+}
+
+// numberKey returns the exact value of a number as a string,
+// false for NaN and infinite floats.
+func numberKey(num value) (string, bool) {
+	if num.kind == floatValue && (math.IsNaN(num.float) || math.IsInf(num.float, 0)) {
+		return "", false
+	}
+
+	return toDecimal(num).RatString(), true
+}
+
+// hashKey returns a key which is equal for values '=' finds equal: numbers and strings holding
+// numbers by the exact number, dates and strings holding dates by the time, other strings lowercased.
+func hashKey(val value) string {
+	switch val.kind {
+	case intValue, decimalValue, floatValue:
+		if key, ok := numberKey(val); ok {
+			return "n" + key
+		}
+
+		return "f" + val.String()
+	case dateValue, timestampValue:
+		return "d" + val.time.Format(time.RFC3339Nano)
+	case stringValue:
+		if num, ok := parseNumber(val.str); ok {
+			return hashKey(num)
+		}
+
+		if date, ok := parseDate(val.str); ok {
+			return hashKey(date)
+		}
+
+		return "s" + strings.ToLower(val.str)
+	}
+
+	return "b" + val.String()
+}
+
+func (c *CsvParser) evaluateIn(exp, list *parsing.Expression, row []string) (value, error) {
+	val, err := c.evaluate(exp, row)
+	if err != nil {
+		return value{}, err
+	}
+
+	if isNull(val) {
+		return val, nil
+	}
+
+	if list.Kind == parsing.SubqueryKind {
+		return c.evaluateInSubquery(val, list, row)
+	}
+
+	if set, ok := c.compiled.inSets[list]; ok && set.accepts(val) {
+		found, err := set.contains(val)
+		if err != nil {
+			return value{}, err
+		}
+
+		return value{kind: boolValue, boolean: found}, nil
+	}
+
+	// 'a IN (b, NULL)' is NULL when a is not b
+	found := value{kind: boolValue, boolean: false}
+	for _, item := range list.List {
+		itemVal, err := c.evaluate(item, row)
+		if err != nil {
+			return value{}, err
+		}
+
+		if isNull(itemVal) {
+			found = itemVal
+			continue
+		}
+
+		cmp, err := compareValues(val, itemVal)
+		if err != nil {
+			return value{}, err
+		}
+
+		if cmp == 0 {
+			return value{kind: boolValue, boolean: true}, nil
+		}
+	}
+
+	return found, nil
+}
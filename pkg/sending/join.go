@@ -0,0 +1,284 @@
+package sending
+
+import (
+	"course_project/pkg/parsing"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// joinKey is a pair of columns compared with '=' in ON, left is the index in the left
+// model and right in the right one.
+type joinKey struct {
+	left  int
+	right int
+}
+
+// addTable adds the csv of the parser to the tables, so derived parsers for subqueries find it.
+func (c *CsvParser) addTable() error {
+	if c.tables == nil {
+		c.tables = map[string]*CsvModel{}
+	}
+
+	if _, ok := c.tables[c.tableName]; ok {
+		return nil
+	}
+
+	c.tables[c.tableName] = c.csvModel
+
+	return nil
+}
+
+// table returns the csv with the name, other than the csv of the parser it is read from
+// the same directory once.
+func (c *CsvParser) table(name string) (*CsvModel, error) {
+	if table, ok := c.with.lookup(name); ok {
+		// results of subqueries are reused, so they cannot depend on the step of a recursive query
+		if table.isWorking && c.outer != table.outer {
+			return nil, fmt.Errorf("recursive reference to query '%s' must not appear within a subquery", name)
+		}
+
+		return table.model, nil
+	}
+
+	if model, ok := c.tables[name]; ok {
+		return model, nil
+	}
+
+	if c.csvFilePath == "" || strings.ContainsAny(name, `/\`) {
+		return nil, fmt.Errorf("can`t find csv with name '%s'", name)
+	}
+
+	path := filepath.Join(filepath.Dir(c.csvFilePath), name+".csv")
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("can`t find csv with name '%s'", name)
+	}
+
+	// other csv files have the same NULL cells as the csv of the parser
+	model := &CsvModel{}
+	if main, ok := c.tables[c.tableName]; ok {
+		model.nullValues = main.nullValues
+	}
+
+	t := &CsvParser{csvFilePath: path, tableName: name, csvModel: model}
+	if err := t.initCsvModel(); err != nil {
+		return nil, err
+	}
+	model.setDateColumns(c.dateColumns)
+
+	c.tables[name] = t.csvModel
+
+	return t.csvModel, nil
+}
+
+// from returns the parser for the rows of FROM with joins applied left to right.
+func (c *CsvParser) from(request *parsing.SelectStatement) (*CsvParser, error) {
+	source, qualifier, err := c.fromItem(request.From, request.FromSubquery, request.FromAlias)
+	if err != nil {
+		return nil, err
+	}
+	qualifiers := map[string]bool{qualifier: true}
+
+	for _, join := range request.Joins {
+		model, qualifier, err := c.fromItem(join.Table, join.Subquery, join.Alias)
+		if err != nil {
+			return nil, err
+		}
+
+		if qualifiers[qualifier] {
+			return nil, fmt.Errorf("table name '%s' specified more than once", qualifier)
+		}
+		qualifiers[qualifier] = true
+
+		source, err = c.join(source, model, join)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return c.derive(source), nil
+}
+
+// fromItem returns the rows of a table or a subquery of FROM, qualified with the alias
+// or the name of the table.
+func (c *CsvParser) fromItem(table parsing.Token, subquery *parsing.SelectStatement, alias string) (*CsvModel, string, error) {
+	qualifier := table.Value
+	if alias != "" {
+		qualifier = alias
+	}
+
+	if subquery != nil {
+		result, err := c.derive(nil).query(subquery)
+		if err != nil {
+			return nil, "", err
+		}
+
+		return result.qualified(qualifier), qualifier, nil
+	}
+
+	model, err := c.table(table.Value)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return model.qualified(qualifier), qualifier, nil
+}
+
+// join returns the rows of left and right matched by ON. Equality of columns from different
+// sides is checked with a hash table of right rows, other conditions for each pair of rows
+// with the same keys.
+func (c *CsvParser) join(left, right *CsvModel, join *parsing.Join) (*CsvModel, error) {
+	joined := &CsvModel{
+		columnsName: append(append([]string{}, left.columnsName...), right.columnsName...),
+		qualifiers:  append(append([]string{}, left.qualifiers...), right.qualifiers...),
+		nullValues:  left.nullValues,
+	}
+	if left.formats != nil || right.formats != nil {
+		joined.formats = make([]*dateFormat, len(left.columnsName), len(joined.columnsName))
+		copy(joined.formats, left.formats)
+		joined.formats = append(joined.formats, make([]*dateFormat, len(right.columnsName))...)
+		copy(joined.formats[len(left.columnsName):], right.formats)
+	}
+	parser := c.derive(joined)
+
+	var keys []joinKey
+	var conditions []*parsing.Expression
+	if join.On != nil {
+		if val, ok := parser.checkExpressionColumnName(join.On); !ok {
+			return nil, parser.columnError(val)
+		}
+
+		keys, conditions = joinKeys(join.On, left, right)
+	}
+
+	leftPad := make([]string, len(left.columnsName))
+	rightPad := make([]string, len(right.columnsName))
+	for idx := range leftPad {
+		leftPad[idx] = joined.nullCell()
+	}
+	for idx := range rightPad {
+		rightPad[idx] = joined.nullCell()
+	}
+
+	var byKey map[string][]int
+	if len(keys) != 0 {
+		byKey = map[string][]int{}
+		for idx, row := range right.data {
+			if key, ok := rowKey(right, row, keys, false); ok {
+				byKey[key] = append(byKey[key], idx)
+			}
+		}
+	}
+
+	isMatchedRight := make([]bool, len(right.data))
+	for _, leftRow := range left.data {
+		var candidates []int
+		if byKey == nil {
+			candidates = make([]int, len(right.data))
+			for idx := range candidates {
+				candidates[idx] = idx
+			}
+		} else if key, ok := rowKey(left, leftRow, keys, true); ok {
+			candidates = byKey[key]
+		}
+
+		isMatched := false
+		for _, idx := range candidates {
+			row := append(append(make([]string, 0, len(joined.columnsName)), leftRow...), right.data[idx]...)
+
+			ok, err := parser.matchAll(conditions, row)
+			if err != nil {
+				return nil, err
+			}
+
+			if ok {
+				joined.data = append(joined.data, row)
+				isMatched = true
+				isMatchedRight[idx] = true
+			}
+		}
+
+		if !isMatched && (join.Kind == parsing.LeftJoin || join.Kind == parsing.FullJoin) {
+			joined.data = append(joined.data, append(append([]string{}, leftRow...), rightPad...))
+		}
+	}
+
+	if join.Kind == parsing.RightJoin || join.Kind == parsing.FullJoin {
+		for idx, rightRow := range right.data {
+			if !isMatchedRight[idx] {
+				joined.data = append(joined.data, append(append([]string{}, leftPad...), rightRow...))
+			}
+		}
+	}
+
+	return joined, nil
+}
+
+// joinKeys splits ON by AND into equalities of a left and a right column, which are used
+// as hash keys, and other conditions.
+func joinKeys(on *parsing.Expression, left, right *CsvModel) ([]joinKey, []*parsing.Expression) {
+	if on.Kind == parsing.BinaryKind && on.Binary.Op.Kind == parsing.KeywordKind &&
+		parsing.Keyword(on.Binary.Op.Value) == parsing.AndKeyword {
+		keys, conditions := joinKeys(on.Binary.A, left, right)
+		bKeys, bConditions := joinKeys(on.Binary.B, left, right)
+
+		return append(keys, bKeys...), append(conditions, bConditions...)
+	}
+
+	if on.Kind != parsing.BinaryKind || on.Binary.Op.Kind != parsing.OperationKind ||
+		parsing.Operation(on.Binary.Op.Value) != parsing.EqualsOperation ||
+		!isColumn(on.Binary.A) || !isColumn(on.Binary.B) {
+		return nil, []*parsing.Expression{on}
+	}
+
+	a, b := on.Binary.A.Literal.Value, on.Binary.B.Literal.Value
+	if left.FindColumnNameFromCsv(a) && right.FindColumnNameFromCsv(b) {
+		return []joinKey{{left: left.GetIdxColumnName(a), right: right.GetIdxColumnName(b)}}, nil
+	}
+	if left.FindColumnNameFromCsv(b) && right.FindColumnNameFromCsv(a) {
+		return []joinKey{{left: left.GetIdxColumnName(b), right: right.GetIdxColumnName(a)}}, nil
+	}
+
+	return nil, []*parsing.Expression{on}
+}
+
+func isColumn(exp *parsing.Expression) bool {
+	return exp.Kind == parsing.LiteralKind && exp.Literal.Kind == parsing.IdentifierKind
+}
+
+// rowKey returns the hash key of the row made of hashKey of the cells, so rows '=' finds
+// equal have one key, rows with a NULL key match nothing.
+func rowKey(model *CsvModel, row []string, keys []joinKey, isLeft bool) (string, bool) {
+	var key strings.Builder
+	for _, k := range keys {
+		idx := k.right
+		if isLeft {
+			idx = k.left
+		}
+
+		val := model.cell(row, idx)
+		if isNull(val) {
+			return "", false
+		}
+
+		cell := hashKey(val)
+		key.WriteString(strconv.Itoa(len(cell)))
+		key.WriteByte(':')
+		key.WriteString(cell)
+	}
+
+	return key.String(), true
+}
+
+func (c *CsvParser) matchAll(conditions []*parsing.Expression, row []string) (bool, error) {
+	for _, condition := range conditions {
+		ok, err := c.evaluateCondition(condition, row)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,215 @@
+package sending
+
+import (
+	"course_project/pkg/parsing"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// defaultLikeEscape is used without ESCAPE clause, as in postgres.
+const defaultLikeEscape = `\`
+
+// likePattern is a LIKE pattern with escapes resolved, compiled once per query
+// when the pattern is a literal.
+type likePattern struct {
+	runes []rune
+	// wildcard marks '%' and '_' which are not escaped
+	wildcard    []bool
+	insensitive bool
+
+	// simple patterns, without '_' and with '%' only at the ends, are matched
+	// with strings functions
+	simple   bool
+	literal  string
+	anyStart bool
+	anyEnd   bool
+}
+
+func compileLike(pattern, escape string, insensitive bool) (*likePattern, error) {
+	esc := []rune(escape)
+	if len(esc) > 1 {
+		return nil, fmt.Errorf("invalid escape string '%s', it must be empty or one character", escape)
+	}
+
+	p := &likePattern{insensitive: insensitive}
+
+	runes := []rune(pattern)
+	for idx := 0; idx < len(runes); idx++ {
+		r := runes[idx]
+		isWildcard := r == '%' || r == '_'
+
+		if len(esc) == 1 && r == esc[0] {
+			idx++
+			if idx == len(runes) {
+				return nil, fmt.Errorf("LIKE pattern must not end with escape character")
+			}
+
+			r = runes[idx]
+			isWildcard = false
+		}
+
+		if insensitive && !isWildcard {
+			r = unicode.ToLower(r)
+		}
+
+		p.runes = append(p.runes, r)
+		p.wildcard = append(p.wildcard, isWildcard)
+	}
+
+	start, end := 0, len(p.runes)
+	for start < end && p.wildcard[start] && p.runes[start] == '%' {
+		start++
+	}
+	for end > start && p.wildcard[end-1] && p.runes[end-1] == '%' {
+		end--
+	}
+
+	p.simple = true
+	for idx := start; idx < end; idx++ {
+		if p.wildcard[idx] {
+			p.simple = false
+		}
+	}
+
+	if p.simple {
+		p.literal = string(p.runes[start:end])
+		p.anyStart = start > 0
+		p.anyEnd = end < len(p.runes)
+	}
+
+	return p, nil
+}
+
+func (p *likePattern) match(data string) bool {
+	if p.insensitive {
+		data = strings.ToLower(data)
+	}
+
+	if p.simple {
+		switch {
+		case p.anyStart && p.anyEnd:
+			return strings.Contains(data, p.literal)
+		case p.anyStart:
+			return strings.HasSuffix(data, p.literal)
+		case p.anyEnd:
+			return strings.HasPrefix(data, p.literal)
+		}
+
+		return data == p.literal
+	}
+
+	d := []rune(data)
+
+	// positions to come back to after a mismatch following the last '%'
+	starIdx, matchIdx := -1, 0
+	i, j := 0, 0
+	for i < len(d) {
+		switch {
+		case j < len(p.runes) && p.wildcard[j] && p.runes[j] == '%':
+			starIdx = j
+			matchIdx = i
+			j++
+		case j < len(p.runes) && ((p.wildcard[j] && p.runes[j] == '_') || (!p.wildcard[j] && p.runes[j] == d[i])):
+			i++
+			j++
+		case starIdx != -1:
+			j = starIdx + 1
+			matchIdx++
+			i = matchIdx
+		default:
+			return false
+		}
+	}
+
+	for j < len(p.runes) && p.wildcard[j] && p.runes[j] == '%' {
+		j++
+	}
+
+	return j == len(p.runes)
+}
+
+// bindLike compiles the pattern when it does not depend on the row.
+func (c *CsvParser) bindLike(like *parsing.LikeExpression) error {
+	if !isStringLiteral(like.Pattern) || (like.Escape != nil && !isStringLiteral(like.Escape)) {
+		return nil
+	}
+
+	escape := defaultLikeEscape
+	if like.Escape != nil {
+		escape = like.Escape.Literal.Value
+	}
+
+	pattern, err := compileLike(like.Pattern.Literal.Value, escape, isIlike(like))
+	if err != nil {
+		return err
+	}
+
+	c.compiled.likePatterns[like] = pattern
+
+	return nil
+}
+
+func (c *CsvParser) evaluateLike(like *parsing.LikeExpression, row []string) (value, error) {
+	val, err := c.evaluate(like.A, row)
+	if err != nil {
+		return value{}, err
+	}
+
+	if isNull(val) {
+		return val, nil
+	}
+
+	if val.kind != stringValue {
+		return value{}, fmt.Errorf("types do not match. LIKE works only with strings")
+	}
+
+	pattern, ok := c.compiled.likePatterns[like]
+	if !ok {
+		pattern, err = c.compileRowLike(like, row)
+		if err != nil {
+			return value{}, err
+		}
+
+		if pattern == nil {
+			return value{kind: nullValue}, nil
+		}
+	}
+
+	return value{kind: boolValue, boolean: pattern.match(val.str)}, nil
+}
+
+// compileRowLike compiles a pattern which depends on the row, for example a column,
+// it returns nil for a NULL pattern.
+func (c *CsvParser) compileRowLike(like *parsing.LikeExpression, row []string) (*likePattern, error) {
+	patternVal, err := c.evaluate(like.Pattern, row)
+	if err != nil {
+		return nil, err
+	}
+
+	escapeVal := value{kind: stringValue, str: defaultLikeEscape}
+	if like.Escape != nil {
+		escapeVal, err = c.evaluate(like.Escape, row)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if isNull(patternVal) || isNull(escapeVal) {
+		return nil, nil
+	}
+
+	if patternVal.kind != stringValue || escapeVal.kind != stringValue {
+		return nil, fmt.Errorf("types do not match. LIKE works only with strings")
+	}
+
+	return compileLike(patternVal.str, escapeVal.str, isIlike(like))
+}
+
+func isIlike(like *parsing.LikeExpression) bool {
+	return parsing.Keyword(like.Op.Value) == parsing.IlikeKeyword
+}
+
+func isStringLiteral(exp *parsing.Expression) bool {
+	return exp.Kind == parsing.LiteralKind && exp.Literal.Kind == parsing.StringKind
+}
@@ -0,0 +1,216 @@
+package sending
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// maxDecimalDigits is the number of fractional digits a decimal which has no exact
+// finite form, like 1 / 3, is rounded to when it is written.
+const maxDecimalDigits = 16
+
+// maxRoundDigits limits digits of ROUND, as in postgres the scale of a numeric is at most 1000.
+const maxRoundDigits = 1000
+
+// parseNumber reads a number: integers are int64, numbers with a fraction are exact decimals,
+// numbers with an exponent are float. Integers which do not fit int64 are decimals. Text like
+// 'NaN' or 'Inf' is not a number, so such cells are compared as strings.
+func parseNumber(str string) (value, bool) {
+	if num, err := strconv.ParseInt(str, 10, 64); err == nil {
+		return value{kind: intValue, num: num}, true
+	}
+
+	if isDecimalString(str) {
+		if r, ok := new(big.Rat).SetString(str); ok {
+			return value{kind: decimalValue, decimal: r}, true
+		}
+	}
+
+	if num, err := strconv.ParseFloat(str, 64); err == nil && !math.IsNaN(num) && !math.IsInf(num, 0) {
+		return value{kind: floatValue, float: num}, true
+	}
+
+	return value{}, false
+}
+
+// isDecimalString reports whether str is digits with an optional sign and fraction, like -12.50.
+func isDecimalString(str string) bool {
+	if strings.HasPrefix(str, "-") || strings.HasPrefix(str, "+") {
+		str = str[1:]
+	}
+
+	digits := 0
+	period := false
+	for _, c := range str {
+		switch {
+		case c >= '0' && c <= '9':
+			digits++
+		case c == '.' && !period:
+			period = true
+		default:
+			return false
+		}
+	}
+
+	return digits != 0
+}
+
+func toDecimal(v value) *big.Rat {
+	switch v.kind {
+	case intValue:
+		return new(big.Rat).SetInt64(v.num)
+	case floatValue:
+		// only finite floats are converted, see promote
+		r, _ := new(big.Rat).SetString(strconv.FormatFloat(v.float, 'f', -1, 64))
+		return r
+	}
+
+	return v.decimal
+}
+
+func toFloat(v value) float64 {
+	switch v.kind {
+	case intValue:
+		return float64(v.num)
+	case decimalValue:
+		num, _ := v.decimal.Float64()
+		return num
+	}
+
+	return v.float
+}
+
+// promote converts two numbers to the same kind: int, then decimal, then float,
+// as in postgres a float with an exact number gives a float.
+func promote(l, r value) (value, value) {
+	switch {
+	case l.kind == r.kind:
+		return l, r
+	case l.kind == floatValue || r.kind == floatValue:
+		return value{kind: floatValue, float: toFloat(l)}, value{kind: floatValue, float: toFloat(r)}
+	}
+
+	return value{kind: decimalValue, decimal: toDecimal(l)}, value{kind: decimalValue, decimal: toDecimal(r)}
+}
+
+// compareNumbers returns -1, 0 or 1, decimals are compared exactly.
+func compareNumbers(l, r value) int {
+	l, r = promote(l, r)
+
+	switch l.kind {
+	case intValue:
+		switch {
+		case l.num < r.num:
+			return -1
+		case l.num > r.num:
+			return 1
+		}
+
+		return 0
+	case decimalValue:
+		return l.decimal.Cmp(r.decimal)
+	}
+
+	return compareFloats(l.float, r.float)
+}
+
+func isZero(v value) bool {
+	switch v.kind {
+	case intValue:
+		return v.num == 0
+	case decimalValue:
+		return v.decimal.Sign() == 0
+	}
+
+	return v.float == 0
+}
+
+// integerValue returns an integer decimal as int, when it fits int64.
+func integerValue(r *big.Rat) value {
+	if r.IsInt() && r.Num().IsInt64() {
+		return value{kind: intValue, num: r.Num().Int64()}
+	}
+
+	return value{kind: decimalValue, decimal: r}
+}
+
+// formatDecimal writes a decimal with as many fractional digits as it has,
+// up to maxDecimalDigits, without trailing zeros.
+func formatDecimal(r *big.Rat) string {
+	if r.IsInt() {
+		return r.Num().String()
+	}
+
+	digits := fractionDigits(r.Denom())
+	if digits == -1 || digits > maxDecimalDigits {
+		str := strings.TrimRight(r.FloatString(maxDecimalDigits), "0")
+		return strings.TrimSuffix(str, ".")
+	}
+
+	return r.FloatString(digits)
+}
+
+// fractionDigits returns the number of fractional digits of a decimal with the denominator,
+// -1 when it has no finite form: the denominator has factors other than 2 and 5.
+func fractionDigits(denom *big.Int) int {
+	d := new(big.Int).Set(denom)
+	two, five, mod := big.NewInt(2), big.NewInt(5), new(big.Int)
+
+	twos, fives := 0, 0
+	for mod.Mod(d, two).Sign() == 0 {
+		d.Quo(d, two)
+		twos++
+	}
+	for mod.Mod(d, five).Sign() == 0 {
+		d.Quo(d, five)
+		fives++
+	}
+
+	if d.Cmp(big.NewInt(1)) != 0 {
+		return -1
+	}
+
+	if twos > fives {
+		return twos
+	}
+
+	return fives
+}
+
+// roundDecimal rounds to the number of fractional digits, halves are rounded away from zero.
+func roundDecimal(r *big.Rat, digits int) *big.Rat {
+	// a decimal with no more fractional digits stays as it is
+	if precision := fractionDigits(r.Denom()); precision != -1 && digits >= precision {
+		return r
+	}
+
+	shift := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(math.Abs(float64(digits)))), nil))
+	if digits < 0 {
+		shift.Inv(shift)
+	}
+
+	scaled := new(big.Rat).Mul(r, shift)
+	half := big.NewRat(1, 2)
+	if scaled.Sign() < 0 {
+		half.Neg(half)
+	}
+	scaled.Add(scaled, half)
+
+	// Quo truncates towards zero
+	rounded := new(big.Int).Quo(scaled.Num(), scaled.Denom())
+
+	return new(big.Rat).Quo(new(big.Rat).SetInt(rounded), shift)
+}
+
+// floorDecimal returns the largest integer not greater than r.
+func floorDecimal(r *big.Rat) *big.Rat {
+	// Div rounds towards negative infinity for a positive denominator
+	return new(big.Rat).SetInt(new(big.Int).Div(r.Num(), r.Denom()))
+}
+
+func ceilDecimal(r *big.Rat) *big.Rat {
+	floor := floorDecimal(new(big.Rat).Neg(r))
+	return floor.Neg(floor)
+}
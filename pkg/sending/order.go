@@ -0,0 +1,116 @@
+package sending
+
+import (
+	"course_project/pkg/parsing"
+	"sort"
+	"strings"
+)
+
+type sortKey struct {
+	null bool
+	num  value
+	str  string
+}
+
+// sortRows orders rows by the ORDER BY items. A key is compared numerically
+// when all of its non empty values are numbers, otherwise as a string.
+func (c *CsvParser) sortRows(rows [][]string, orderBy []*parsing.OrderItem) ([][]string, error) {
+	if len(orderBy) == 0 {
+		return rows, nil
+	}
+
+	keys, numeric, err := c.orderKeys(rows, orderBy)
+	if err != nil {
+		return rows, err
+	}
+
+	sorted := make([][]string, 0, len(rows))
+	for _, idx := range sortOrder(keys, numeric, orderBy) {
+		sorted = append(sorted, rows[idx])
+	}
+
+	return sorted, nil
+}
+
+// orderKeys evaluates the ORDER BY items for every row, numeric reports
+// the items compared as numbers.
+func (c *CsvParser) orderKeys(rows [][]string, orderBy []*parsing.OrderItem) ([][]sortKey, []bool, error) {
+	keys := make([][]sortKey, len(rows))
+	for idx := range keys {
+		keys[idx] = make([]sortKey, len(orderBy))
+	}
+
+	numeric := make([]bool, len(orderBy))
+	for idxItem, item := range orderBy {
+		numeric[idxItem] = true
+
+		for idx, str := range rows {
+			val, err := c.evaluate(item.Exp, str)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			key := sortKey{str: strings.ToLower(val.String())}
+			if isNull(val) {
+				key.null = true
+			} else if num, ok := parseNumber(key.str); ok {
+				key.num = num
+			} else {
+				numeric[idxItem] = false
+			}
+
+			keys[idx][idxItem] = key
+		}
+	}
+
+	return keys, numeric, nil
+}
+
+// sortOrder returns indexes of the keys in the sorted order, equal keys keep their order.
+func sortOrder(keys [][]sortKey, numeric []bool, orderBy []*parsing.OrderItem) []int {
+	order := make([]int, len(keys))
+	for idx := range order {
+		order[idx] = idx
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return compareKeys(keys[order[i]], keys[order[j]], numeric, orderBy) < 0
+	})
+
+	return order
+}
+
+func compareKeys(a, b []sortKey, numeric []bool, orderBy []*parsing.OrderItem) int {
+	for idxItem, item := range orderBy {
+		cmp := compareSortKeys(a[idxItem], b[idxItem], item, numeric[idxItem])
+		if cmp != 0 {
+			return cmp
+		}
+	}
+
+	return 0
+}
+
+func compareSortKeys(a, b sortKey, item *parsing.OrderItem, numeric bool) int {
+	if a.null || b.null {
+		switch {
+		case a.null && b.null:
+			return 0
+		case a.null == item.NullsFirst:
+			return -1
+		}
+
+		return 1
+	}
+
+	cmp := strings.Compare(a.str, b.str)
+	if numeric {
+		cmp = compareNumbers(a.num, b.num)
+	}
+
+	if item.Desc {
+		return -cmp
+	}
+
+	return cmp
+}
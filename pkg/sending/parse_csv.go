@@ -5,7 +5,7 @@ import (
 	"encoding/csv"
 	"fmt"
 	"os"
-	"strconv"
+	"regexp"
 	"strings"
 )
 
@@ -13,18 +13,59 @@ type CsvParser struct {
 	csvFilePath string
 	tableName   string
 	csvModel    *CsvModel
+	compiled    *compiled
+	// tables are the csv of the parser and other csv files from the directory of csvFilePath,
+	// read by FROM and JOIN
+	tables map[string]*CsvModel
+	// outer is the row of the query a subquery is run for
+	outer *scope
+	// with holds results of WITH queries, they are found before csv files
+	with *commonTables
+	// dateColumns are formats of columns read as dates, for all csv files
+	dateColumns map[string]*dateFormat
 }
 
-func New(csv string) (*CsvParser, error) {
+// compiled holds what bindRequest prepares once per query instead of once per row.
+type compiled struct {
+	likePatterns map[*parsing.LikeExpression]*likePattern
+	// inSets are keyed by the list of IN
+	inSets map[*parsing.Expression]*inSet
+	// regexps are keyed by the pattern expression
+	regexps map[*parsing.Expression]*regexp.Regexp
+	// subqueries are results of subqueries which do not refer to outer queries
+	subqueries map[*parsing.SelectStatement]*CsvModel
+}
+
+// New reads the csv file, cells equal to one of nullValues are NULL. Without nullValues
+// empty cells are NULL. dateColumns are columns read as dates with their formats, like 'YYYY.MM'.
+func New(csv string, nullValues []string, dateColumns map[string]string) (*CsvParser, error) {
 	pathend := strings.LastIndex(csv, "/")
 	ext := strings.LastIndex(csv, ".csv")
 
 	tableName := csv[pathend+1 : ext]
 
-	c := &CsvParser{csvFilePath: csv, csvModel: &CsvModel{}, tableName: tableName}
+	model := &CsvModel{}
+	if len(nullValues) != 0 {
+		model.nullValues = map[string]bool{}
+		for _, val := range nullValues {
+			model.nullValues[val] = true
+		}
+	}
+
+	c := &CsvParser{csvFilePath: csv, csvModel: model, tableName: tableName, dateColumns: map[string]*dateFormat{}}
+	for name, format := range dateColumns {
+		parsed, err := parseDateFormat(format)
+		if err != nil {
+			return c, fmt.Errorf("date column '%s': %v", name, err)
+		}
+
+		c.dateColumns[strings.ToLower(name)] = parsed
+	}
+
 	if err := c.initCsvModel(); err != nil {
 		return c, err
 	}
+	model.setDateColumns(c.dateColumns)
 
 	return c, nil
 }
@@ -58,236 +99,389 @@ func (c *CsvParser) initCsvModel() error {
 }
 
 func (c *CsvParser) SendRequest(request *parsing.SelectStatement) ([][]string, error) {
-	if val, ok := c.checkExistingColumnName(request); !ok {
-		return [][]string{}, fmt.Errorf("no such column name '%s' in csv", val)
+	if err := c.bindRequest(request); err != nil {
+		return [][]string{}, err
 	}
 
-	err := c.csvModel.checkOnInt()
+	if err := c.addTable(); err != nil {
+		return [][]string{}, err
+	}
+
+	result, err := c.query(request)
 	if err != nil {
 		return [][]string{}, err
 	}
-	res, err := c.request(request)
 
+	if isAllItems(request) {
+		return result.data, nil
+	}
+
+	return append([][]string{result.columnsName}, result.data...), nil
+}
+
+// isAllItems reports whether the result is SELECT *, which is written without the header,
+// for UNION and others it is decided by the first select.
+func isAllItems(request *parsing.SelectStatement) bool {
+	for request.Compound != nil {
+		request = request.Compound.Left
+	}
+
+	return request.IsAllItems
+}
+
+// query runs a bound statement and returns the header and the rows as a model,
+// so the result of a subquery is read like a csv.
+func (c *CsvParser) query(request *parsing.SelectStatement) (*CsvModel, error) {
+	if request.With != nil {
+		inner, err := c.withTables(request.With)
+		if err != nil {
+			return nil, err
+		}
+
+		c = inner
+	}
+
+	if request.Compound != nil {
+		return c.compoundQuery(request)
+	}
+
+	if request.From.Value == "" && request.FromSubquery == nil {
+		return c.constantQuery(request)
+	}
+
+	source, err := c.from(request)
 	if err != nil {
-		return res, err
+		return nil, err
 	}
 
-	return res, nil
+	request = source.resolveAliases(request)
+
+	if val, ok := source.checkExistingColumnName(request); !ok {
+		return nil, source.columnError(val)
+	}
+
+	return source.request(request)
 }
 
-func (c *CsvParser) checkExistingColumnName(request *parsing.SelectStatement) (string, bool) {
-	for idx := range request.Item {
-		item := request.Item[idx]
+// derive returns a parser for rows of the model, like joined or grouped ones, which
+// shares tables and compiled parts of the query with c.
+func (c *CsvParser) derive(model *CsvModel) *CsvParser {
+	return &CsvParser{
+		csvFilePath: c.csvFilePath,
+		tableName:   c.tableName,
+		csvModel:    model,
+		compiled:    c.compiled,
+		tables:      c.tables,
+		outer:       c.outer,
+		with:        c.with,
+		dateColumns: c.dateColumns,
+	}
+}
+
+func (c *CsvParser) columnError(name string) error {
+	if c.csvModel.isAmbiguous(name) {
+		return fmt.Errorf("column reference '%s' is ambiguous", name)
+	}
 
-		if !c.csvModel.FindColumnNameFromCsv(item.Literal.Value) {
-			return item.Literal.Value, false
+	return fmt.Errorf("no such column name '%s' in csv", name)
+}
+
+// constantQuery runs a select without FROM, like 'SELECT 1;', against a single empty row.
+func (c *CsvParser) constantQuery(request *parsing.SelectStatement) (*CsvModel, error) {
+	if request.IsAllItems {
+		return nil, fmt.Errorf("SELECT * requires FROM")
+	}
+
+	constant := c.derive(&CsvModel{data: [][]string{{}}})
+	request = constant.resolveAliases(request)
+
+	if val, ok := constant.checkExistingColumnName(request); !ok {
+		return nil, fmt.Errorf("no such column name '%s', query has no FROM", val)
+	}
+
+	return constant.request(request)
+}
+
+// resolveAliases replaces select list aliases used as GROUP BY and ORDER BY items with
+// the aliased expressions. As in postgres GROUP BY prefers a csv column with the same name
+// and ORDER BY prefers the alias.
+func (c *CsvParser) resolveAliases(request *parsing.SelectStatement) *parsing.SelectStatement {
+	aliases := map[string]*parsing.Expression{}
+	for _, item := range request.Item {
+		if item.Alias != "" {
+			aliases[item.Alias] = item
 		}
 	}
 
-	for _, val := range request.Where {
-		item, ok := val.(parsing.Conditions)
-		if ok {
-			if !c.csvModel.FindColumnNameFromCsv(item.Literal.Value) {
-				return item.Literal.Value, false
-			}
+	if len(aliases) == 0 {
+		return request
+	}
+
+	aliasOf := func(exp *parsing.Expression) (*parsing.Expression, bool) {
+		if exp.Kind != parsing.LiteralKind || exp.Literal.Kind != parsing.IdentifierKind {
+			return nil, false
 		}
+
+		aliased, ok := aliases[exp.Literal.Value]
+		return aliased, ok
 	}
 
-	return "", true
+	resolved := *request
+
+	resolved.GroupBy = make([]*parsing.Expression, 0, len(request.GroupBy))
+	for _, exp := range request.GroupBy {
+		if aliased, ok := aliasOf(exp); ok && !c.csvModel.FindColumnNameFromCsv(exp.Literal.Value) {
+			exp = aliased
+		}
+
+		resolved.GroupBy = append(resolved.GroupBy, exp)
+	}
+
+	resolved.OrderBy = make([]*parsing.OrderItem, 0, len(request.OrderBy))
+	for _, item := range request.OrderBy {
+		if aliased, ok := aliasOf(item.Exp); ok {
+			resolvedItem := *item
+			resolvedItem.Exp = aliased
+			item = &resolvedItem
+		}
+
+		resolved.OrderBy = append(resolved.OrderBy, item)
+	}
+
+	return &resolved
 }
 
-//gocyclo:ignore
-func (c *CsvParser) request(request *parsing.SelectStatement) ([][]string, error) {
-	var columnInput []int
-	var resultData [][]string
+func (c *CsvParser) checkExistingColumnName(request *parsing.SelectStatement) (string, bool) {
+	for _, item := range request.Item {
+		if val, ok := c.checkExpressionColumnName(item); !ok {
+			return val, false
+		}
+	}
 
-	if request.From.Value != c.tableName {
-		return resultData, fmt.Errorf("can`t find csv with name '%s'", request.From.Value)
+	if request.Where != nil {
+		if val, ok := c.checkExpressionColumnName(request.Where); !ok {
+			return val, false
+		}
 	}
 
-	if !request.IsAllItems {
-		var col []string
-		for idx := range request.Item {
-			item := request.Item[idx]
+	for _, item := range request.DistinctOn {
+		if val, ok := c.checkExpressionColumnName(item); !ok {
+			return val, false
+		}
+	}
 
-			columnInput = append(columnInput, c.csvModel.GetIdxColumnName(item.Literal.Value))
-			col = append(col, item.Literal.Value)
+	for _, item := range request.GroupBy {
+		if val, ok := c.checkExpressionColumnName(item); !ok {
+			return val, false
 		}
+	}
 
-		resultData = append(resultData, col)
+	if request.Having != nil {
+		if val, ok := c.checkExpressionColumnName(request.Having); !ok {
+			return val, false
+		}
 	}
 
-	for _, str := range c.csvModel.data {
-		isAdd := false
-		isAndPredicate := false
-		isOrPredicate := false
-
-		for idx := range request.Where {
-			item := request.Where[idx]
-
-			if idx%2 == 0 {
-				val, ok := item.(parsing.Conditions)
-
-				if !ok {
-					return resultData, fmt.Errorf("incorrect expression 'where' format")
-				}
-
-				idxColumn := c.csvModel.GetIdxColumnName(val.Literal.Value)
-				if idxColumn == -1 {
-					return resultData, fmt.Errorf("")
-				}
-
-				ok, err := c.isConditionOperation(str[idxColumn], val.Operation, val.Value)
-
-				if err != nil {
-					return resultData, err
-				}
-
-				if ok {
-					if idx != 0 {
-						if isAndPredicate {
-							if !isAdd {
-								isAdd = false
-								break
-							}
-						}
-					}
-
-					isAdd = true
-				} else {
-					isAdd = false
-
-					if isAndPredicate {
-						break
-					}
-				}
-			} else {
-				val, ok := item.(parsing.Predicate)
-
-				if !ok {
-					return resultData, fmt.Errorf("incorrect expression 'where' format")
-				}
-
-				if val.Predicate.Value == string(parsing.AndKeyword) {
-					if idx != 1 && isOrPredicate {
-						return resultData, fmt.Errorf("cannot combine AND/OR")
-					}
-
-					isAndPredicate = true
-				} else if val.Predicate.Value == string(parsing.OrKeyword) {
-					if idx != 1 && isAndPredicate {
-						return resultData, fmt.Errorf("cannot combine AND/OR")
-					}
-
-					isOrPredicate = true
-				}
-			}
+	for _, item := range request.OrderBy {
+		if val, ok := c.checkExpressionColumnName(item.Exp); !ok {
+			return val, false
 		}
+	}
 
-		if isAdd || len(request.Where) == 0 {
-			if !request.IsAllItems {
-				var strInput []string
-				for _, idx := range columnInput {
-					strInput = append(strInput, str[idx])
-				}
+	return "", true
+}
 
-				resultData = append(resultData, strInput)
-			} else {
-				resultData = append(resultData, str)
-			}
+func (c *CsvParser) checkExpressionColumnName(exp *parsing.Expression) (string, bool) {
+	if exp.Kind == parsing.LiteralKind && exp.Literal.Kind == parsing.IdentifierKind {
+		name := exp.Literal.Value
+		if c.csvModel.FindColumnNameFromCsv(name) {
+			return "", true
+		}
+
+		// a subquery can refer to columns of outer queries
+		if _, ok := c.outer.lookup(name); !ok || c.csvModel.isAmbiguous(name) {
+			return name, false
+		}
+	}
+
+	for _, child := range exp.Children() {
+		if val, ok := c.checkExpressionColumnName(child); !ok {
+			return val, false
 		}
 	}
 
-	return resultData, nil
+	return "", true
 }
 
-//написать тест
-//gocyclo:ignore
-func (c *CsvParser) isConditionOperation(data string, operation parsing.Token, value parsing.Token) (bool, error) {
-	var dataInt, valueInt int
-	var isInt bool
-	var err error
+// request returns the result of the statement, the header is columns names of the csv for SELECT *.
+func (c *CsvParser) request(request *parsing.SelectStatement) (*CsvModel, error) {
+	aggregates, err := c.findAggregates(request)
+	if err != nil {
+		return nil, err
+	}
+	isAggregated := len(request.GroupBy) != 0 || len(aggregates) != 0 || request.Having != nil
+
+	windows, err := findWindows(request)
+	if err != nil {
+		return nil, err
+	}
+
+	// without ORDER BY the first matched rows are the result, so the scan can stop early,
+	// unless windows need all of them
+	maxRows := -1
+	isDistinct := request.Distinct || len(request.DistinctOn) != 0
+	if request.IsLimited && len(request.OrderBy) == 0 && !isAggregated && !isDistinct && len(windows) == 0 {
+		maxRows = request.Offset + request.Limit
+	}
+
+	rows, err := c.filterRows(request.Where, maxRows)
+	if err != nil {
+		return nil, err
+	}
 
-	if value.Kind == parsing.NumericKind {
-		dataInt, err = strconv.Atoi(data)
+	source := c
+	if isAggregated {
+		source, err = c.aggregate(rows, request, aggregates)
 		if err != nil {
-			return false, fmt.Errorf("types do not match. data %s must be type int. %v", data, err)
+			return nil, err
 		}
-		valueInt, err = strconv.Atoi(value.Value)
+
+		rows = source.csvModel.data
+	}
+
+	if len(windows) != 0 {
+		source, err = source.window(rows, windows)
 		if err != nil {
-			return false, err
+			return nil, err
 		}
-		isInt = true
+
+		rows = source.csvModel.data
 	}
 
-	data = strings.ToLower(data)
+	rows, err = source.sortRows(rows, request.OrderBy)
+	if err != nil {
+		return nil, err
+	}
 
-	switch parsing.Operation(operation.Value) {
-	case parsing.EqualsOperation:
-		if isInt {
-			if dataInt == valueInt {
-				return true, nil
-			}
-		} else {
-			if data == value.Value {
-				return true, nil
-			}
+	rows, err = source.distinctOnRows(rows, request.DistinctOn)
+	if err != nil {
+		return nil, err
+	}
+
+	header, rows, err := source.projectRows(rows, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Distinct {
+		rows = distinctRows(rows)
+	}
+
+	rows = limitRows(rows, request)
+
+	return &CsvModel{columnsName: header, data: rows, nullValues: resultNullValues(source.csvModel.nullValues)}, nil
+}
+
+// resultNullValues are cells of a result read as NULL: empty cells, which NULL values
+// are written as, and the ones of the source.
+func resultNullValues(nullValues map[string]bool) map[string]bool {
+	if nullValues == nil {
+		return nil
+	}
+
+	result := map[string]bool{"": true}
+	for cell := range nullValues {
+		result[cell] = true
+	}
+
+	return result
+}
+
+// filterRows returns rows matched by where, maxRows -1 means no restriction.
+func (c *CsvParser) filterRows(where *parsing.Expression, maxRows int) ([][]string, error) {
+	if where == nil {
+		if maxRows != -1 && maxRows < len(c.csvModel.data) {
+			return c.csvModel.data[:maxRows], nil
 		}
-	case parsing.LessEqualOperation:
-		if isInt {
-			if dataInt <= valueInt {
-				return true, nil
-			}
-		} else {
-			if data <= value.Value {
-				return true, nil
-			}
+
+		return c.csvModel.data, nil
+	}
+
+	var rows [][]string
+	for _, str := range c.csvModel.data {
+		if len(rows) == maxRows {
+			break
 		}
-	case parsing.LessOperation:
-		if isInt {
-			if dataInt < valueInt {
-				return true, nil
-			}
-		} else {
-			if data < value.Value {
-				return true, nil
-			}
+
+		ok, err := c.evaluateCondition(where, str)
+		if err != nil {
+			return rows, err
 		}
-	case parsing.MoreEqualOperation:
-		if isInt {
-			if dataInt >= valueInt {
-				return true, nil
-			}
-		} else {
-			if data >= value.Value {
-				return true, nil
-			}
+
+		if ok {
+			rows = append(rows, str)
 		}
-	case parsing.MoreOperation:
-		if isInt {
-			if dataInt > valueInt {
-				return true, nil
-			}
-		} else {
-			if data > value.Value {
-				return true, nil
+	}
+
+	return rows, nil
+}
+
+func limitRows(rows [][]string, request *parsing.SelectStatement) [][]string {
+	if request.Offset >= len(rows) {
+		return nil
+	}
+	rows = rows[request.Offset:]
+
+	if request.IsLimited && request.Limit < len(rows) {
+		rows = rows[:request.Limit]
+	}
+
+	return rows
+}
+
+// projectRows evaluates the select list for every row and returns the header separately.
+func (c *CsvParser) projectRows(rows [][]string, request *parsing.SelectStatement) ([]string, [][]string, error) {
+	if request.IsAllItems {
+		// cells are written as values of items are: NULL is empty, dates of date columns are 2016-06-01
+		resultData := make([][]string, 0, len(rows))
+		for _, str := range rows {
+			cells := make([]string, len(c.csvModel.columnsName))
+			for idx := range cells {
+				cells[idx] = c.csvModel.cell(str, idx).String()
 			}
+
+			resultData = append(resultData, cells)
 		}
-	case parsing.NotEqualOperation:
-		if isInt {
-			if dataInt != valueInt {
-				return true, nil
-			}
-		} else {
-			if data != value.Value {
-				return true, nil
+
+		return c.csvModel.columnsName, resultData, nil
+	}
+
+	var col []string
+	for _, item := range request.Item {
+		if item.Alias != "" {
+			col = append(col, item.Alias)
+			continue
+		}
+
+		col = append(col, item.String())
+	}
+
+	var resultData [][]string
+	for _, str := range rows {
+		var strInput []string
+		for _, item := range request.Item {
+			val, err := c.evaluate(item, str)
+			if err != nil {
+				return col, resultData, err
 			}
+
+			strInput = append(strInput, val.String())
 		}
-	default:
-		return false, fmt.Errorf("operation '%s' does not supported", operation.Value)
+
+		resultData = append(resultData, strInput)
 	}
 
-	return false, nil
+	return col, resultData, nil
 }
 
 func (c *CsvParser) GetColumnNames() []string {
@@ -0,0 +1,133 @@
+package sending
+
+import (
+	"course_project/pkg/parsing"
+	"fmt"
+	"regexp"
+)
+
+func isRegexp(op parsing.Token) bool {
+	if op.Kind == parsing.KeywordKind {
+		return parsing.Keyword(op.Value) == parsing.RegexpKeyword
+	}
+
+	switch parsing.Operation(op.Value) {
+	case parsing.RegexpOperation, parsing.RegexpInsensitiveOperation, parsing.NotRegexpOperation, parsing.NotRegexpInsensitiveOperation:
+		return op.Kind == parsing.OperationKind
+	}
+
+	return false
+}
+
+func isInsensitiveRegexp(op parsing.Token) bool {
+	switch parsing.Operation(op.Value) {
+	case parsing.RegexpInsensitiveOperation, parsing.NotRegexpInsensitiveOperation:
+		return true
+	}
+
+	return false
+}
+
+func compileRegexp(pattern string, insensitive bool) (*regexp.Regexp, error) {
+	source := pattern
+	if insensitive {
+		source = "(?i)" + pattern
+	}
+
+	re, err := regexp.Compile(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression '%s': %v", pattern, err)
+	}
+
+	return re, nil
+}
+
+// bindRegexp compiles the pattern when it does not depend on the row.
+func (c *CsvParser) bindRegexp(pattern *parsing.Expression, insensitive bool) error {
+	if !isStringLiteral(pattern) {
+		return nil
+	}
+
+	re, err := compileRegexp(pattern.Literal.Value, insensitive)
+	if err != nil {
+		return err
+	}
+
+	c.compiled.regexps[pattern] = re
+
+	return nil
+}
+
+// regexpFor returns the compiled pattern, val is the value of the pattern expression in the current row.
+func (c *CsvParser) regexpFor(pattern *parsing.Expression, val value, insensitive bool) (*regexp.Regexp, error) {
+	if re, ok := c.compiled.regexps[pattern]; ok {
+		return re, nil
+	}
+
+	if val.kind != stringValue {
+		return nil, fmt.Errorf("types do not match. regular expressions work only with strings")
+	}
+
+	return compileRegexp(val.str, insensitive)
+}
+
+func (c *CsvParser) evaluateRegexp(binary *parsing.BinaryExpression, row []string) (value, error) {
+	val, err := c.evaluate(binary.A, row)
+	if err != nil {
+		return value{}, err
+	}
+
+	patternVal, err := c.evaluate(binary.B, row)
+	if err != nil {
+		return value{}, err
+	}
+
+	if isNull(val) || isNull(patternVal) {
+		return value{kind: nullValue}, nil
+	}
+
+	if val.kind != stringValue {
+		return value{}, fmt.Errorf("types do not match. regular expressions work only with strings")
+	}
+
+	re, err := c.regexpFor(binary.B, patternVal, isInsensitiveRegexp(binary.Op))
+	if err != nil {
+		return value{}, err
+	}
+
+	matched := re.MatchString(val.str)
+	switch parsing.Operation(binary.Op.Value) {
+	case parsing.NotRegexpOperation, parsing.NotRegexpInsensitiveOperation:
+		matched = !matched
+	}
+
+	return value{kind: boolValue, boolean: matched}, nil
+}
+
+func regexpReplace(args []value, re *regexp.Regexp) (value, error) {
+	return value{kind: stringValue, str: re.ReplaceAllString(args[0].String(), args[2].String())}, nil
+}
+
+// regexpExtract returns the first match or its group, NULL when nothing matches.
+func regexpExtract(args []value, re *regexp.Regexp) (value, error) {
+	group := 0
+	if len(args) == 3 {
+		num, err := toNumber(args[2])
+		if err != nil {
+			return value{}, err
+		}
+
+		if num.kind != intValue || num.num < 0 || num.num > int64(re.NumSubexp()) {
+			return value{}, fmt.Errorf("regexp_extract: no group %s in '%s'", num, re)
+		}
+
+		group = int(num.num)
+	}
+
+	match := re.FindStringSubmatchIndex(args[0].String())
+	if match == nil || match[2*group] == -1 {
+		return value{kind: nullValue}, nil
+	}
+
+	return value{kind: stringValue, str: args[0].String()[match[2*group]:match[2*group+1]]}, nil
+}
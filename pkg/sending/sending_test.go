@@ -2,6 +2,8 @@ package sending
 
 import (
 	"course_project/pkg/parsing"
+	"fmt"
+	"math"
 	"testing"
 
 	"gopkg.in/go-playground/assert.v1"
@@ -35,7 +37,1619 @@ func TestSendRequestCheckColumnFAIL(t *testing.T) {
 	assert.Equal(t, ok, false)
 }
 
-//block helpers
+func TestSendRequestWhereMixedPredicates(t *testing.T) {
+	s := CsvParser{
+		tableName: "business",
+		csvModel: &CsvModel{
+			columnsName: []string{"status", "magnitude"},
+			data: [][]string{
+				{"F", "6"},
+				{"C", "7"},
+				{"R", "8"},
+				{"F", "3"},
+			},
+		},
+	}
+
+	p := parsing.NewParser()
+	sel, err := p.Parse("select * from business where (status = 'f' or status = 'c') and magnitude >= 6;")
+	assert.Equal(t, err, nil)
+
+	res, err := s.SendRequest(sel)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, res, [][]string{{"F", "6"}, {"C", "7"}})
+}
+
+func TestSendRequestWhereNegation(t *testing.T) {
+	s := CsvParser{
+		tableName: "business",
+		csvModel: &CsvModel{
+			columnsName: []string{"status", "units", "magnitude"},
+			data: [][]string{
+				{"F", "Dollars", "6"},
+				{"C", "Number", "7"},
+				{"R", "Dollars", "8"},
+				{"F", "Percent", "3"},
+			},
+		},
+	}
+
+	testData := []struct {
+		InRequest string
+		Result    [][]string
+	}{
+		{
+			InRequest: "select * from business where not (status = 'f' or magnitude <> 8);",
+			Result:    [][]string{{"R", "Dollars", "8"}},
+		},
+		{
+			InRequest: "select * from business where status not in ('f', 'r');",
+			Result:    [][]string{{"C", "Number", "7"}},
+		},
+		{
+			InRequest: "select * from business where units not like 'D%rs';",
+			Result:    [][]string{{"C", "Number", "7"}, {"F", "Percent", "3"}},
+		},
+		{
+			InRequest: "select * from business where magnitude not between 4 and 7;",
+			Result:    [][]string{{"R", "Dollars", "8"}, {"F", "Percent", "3"}},
+		},
+	}
+
+	p := parsing.NewParser()
+	for _, data := range testData {
+		sel, err := p.Parse(data.InRequest)
+		assert.Equal(t, err, nil)
+
+		res, err := s.SendRequest(sel)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, res, data.Result)
+	}
+}
+
+func TestSendRequestWithoutFilter(t *testing.T) {
+	s := CsvParser{
+		tableName: "business",
+		csvModel: &CsvModel{
+			columnsName: []string{"status", "magnitude"},
+			data: [][]string{
+				{"F", "6"},
+				{"C", "7"},
+			},
+		},
+	}
+
+	testData := []struct {
+		InRequest string
+		Result    [][]string
+		Error     error
+	}{
+		{
+			InRequest: "select magnitude from business;",
+			Result:    [][]string{{"magnitude"}, {"6"}, {"7"}},
+		},
+		{
+			InRequest: "select 1, 'a';",
+			Result:    [][]string{{"1", "'a'"}, {"1", "a"}},
+		},
+		{
+			InRequest: "select 1 where 1 = 2;",
+			Result:    [][]string{{"1"}},
+		},
+		{
+			InRequest: "select status;",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("no such column name 'status', query has no FROM"),
+		},
+	}
+
+	p := parsing.NewParser()
+	for _, data := range testData {
+		sel, err := p.Parse(data.InRequest)
+		assert.Equal(t, err, nil)
+
+		res, err := s.SendRequest(sel)
+
+		assert.Equal(t, err, data.Error)
+		assert.Equal(t, res, data.Result)
+	}
+}
+
+func TestSendRequestOrderBy(t *testing.T) {
+	s := CsvParser{
+		tableName: "business",
+		csvModel: &CsvModel{
+			columnsName: []string{"status", "data_value", "magnitude"},
+			data: [][]string{
+				{"F", "1116.386", "6"},
+				{"C", "", "10"},
+				{"F", "99.5", "9"},
+				{"R", "250", "6"},
+			},
+		},
+	}
+
+	testData := []struct {
+		InRequest string
+		Result    [][]string
+	}{
+		{
+			InRequest: "select magnitude from business order by magnitude;",
+			Result:    [][]string{{"magnitude"}, {"6"}, {"6"}, {"9"}, {"10"}},
+		},
+		{
+			InRequest: "select data_value from business order by data_value;",
+			Result:    [][]string{{"data_value"}, {"99.5"}, {"250"}, {"1116.386"}, {""}},
+		},
+		{
+			InRequest: "select data_value from business order by data_value desc nulls last;",
+			Result:    [][]string{{"data_value"}, {"1116.386"}, {"250"}, {"99.5"}, {""}},
+		},
+		{
+			InRequest: "select status, magnitude from business order by status desc, magnitude desc;",
+			Result:    [][]string{{"status", "magnitude"}, {"R", "6"}, {"F", "9"}, {"F", "6"}, {"C", "10"}},
+		},
+	}
+
+	p := parsing.NewParser()
+	for _, data := range testData {
+		sel, err := p.Parse(data.InRequest)
+		assert.Equal(t, err, nil)
+
+		res, err := s.SendRequest(sel)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, res, data.Result)
+	}
+}
+
+func TestSendRequestLimit(t *testing.T) {
+	s := CsvParser{
+		tableName: "business",
+		csvModel: &CsvModel{
+			columnsName: []string{"status", "magnitude"},
+			data: [][]string{
+				{"F", "6"},
+				{"C", "7"},
+				{"R", "8"},
+				{"F", "x"},
+			},
+		},
+	}
+
+	testData := []struct {
+		InRequest string
+		Result    [][]string
+	}{
+		{
+			InRequest: "select status from business limit 2;",
+			Result:    [][]string{{"status"}, {"F"}, {"C"}},
+		},
+		{
+			InRequest: "select status from business limit 2 offset 3;",
+			Result:    [][]string{{"status"}, {"F"}},
+		},
+		{
+			// the scan stops before the row which can't be compared with a number
+			InRequest: "select status from business where magnitude > 6 fetch first 2 rows only;",
+			Result:    [][]string{{"status"}, {"C"}, {"R"}},
+		},
+		{
+			InRequest: "select status from business where status != 'c' order by status limit 1 offset 1;",
+			Result:    [][]string{{"status"}, {"F"}},
+		},
+	}
+
+	p := parsing.NewParser()
+	for _, data := range testData {
+		sel, err := p.Parse(data.InRequest)
+		assert.Equal(t, err, nil)
+
+		res, err := s.SendRequest(sel)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, res, data.Result)
+	}
+}
+
+func TestSendRequestGroupBy(t *testing.T) {
+	s := CsvParser{
+		tableName: "business",
+		csvModel: &CsvModel{
+			columnsName: []string{"status", "units", "data_value", "magnitude"},
+			data: [][]string{
+				{"F", "Dollars", "10.5", "6"},
+				{"C", "Number", "", "7"},
+				{"F", "Dollars", "4.5", "9"},
+				{"R", "Percent", "3", "6"},
+				{"F", "Number", "1", "6"},
+			},
+		},
+	}
+
+	testData := []struct {
+		InRequest string
+		Result    [][]string
+		Error     error
+	}{
+		{
+			InRequest: "select status, count(*), sum(data_value), count(distinct units) from business group by status;",
+			Result: [][]string{
+				{"status", "count(*)", "sum(data_value)", "count(distinct units)"},
+				{"F", "3", "16", "2"},
+				{"C", "1", "", "1"},
+				{"R", "1", "3", "1"},
+			},
+		},
+		{
+			InRequest: "select count(*), count(data_value), avg(data_value), min(magnitude), max(magnitude) from business;",
+			Result: [][]string{
+				{"count(*)", "count(data_value)", "avg(data_value)", "min(magnitude)", "max(magnitude)"},
+				{"5", "4", "4.75", "6", "9"},
+			},
+		},
+		{
+			InRequest: "select magnitude, count(*) from business where status = 'f' group by magnitude order by count(*) desc;",
+			Result:    [][]string{{"magnitude", "count(*)"}, {"6", "2"}, {"9", "1"}},
+		},
+		{
+			InRequest: "select count(*) from business where status = 'x';",
+			Result:    [][]string{{"count(*)"}, {"0"}},
+		},
+		{
+			InRequest: "select status, sum(magnitude) from business group by status having count(*) > 1 or max(units) = 'percent';",
+			Result:    [][]string{{"status", "sum(magnitude)"}, {"F", "21"}, {"R", "6"}},
+		},
+		{
+			InRequest: "select count(*) from business having count(*) > 10;",
+			Result:    [][]string{{"count(*)"}},
+		},
+		{
+			InRequest: "select status, units, count(*) from business group by status;",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("column 'units' must appear in the GROUP BY clause or be used in an aggregate function"),
+		},
+		{
+			InRequest: "select status from business group by status having magnitude > 6;",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("column 'magnitude' must appear in the GROUP BY clause or be used in an aggregate function"),
+		},
+		{
+			InRequest: "select * from business group by status;",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("SELECT * cannot be used with GROUP BY or aggregate functions"),
+		},
+		{
+			InRequest: "select status from business where count(*) > 1;",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("aggregate function 'count(*)' is not allowed here"),
+		},
+		{
+			InRequest: "select sum(units) from business;",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("types do not match. sum works only with numbers, got Dollars"),
+		},
+	}
+
+	p := parsing.NewParser()
+	for _, data := range testData {
+		sel, err := p.Parse(data.InRequest)
+		assert.Equal(t, err, nil)
+
+		res, err := s.SendRequest(sel)
+
+		assert.Equal(t, err, data.Error)
+		assert.Equal(t, res, data.Result)
+	}
+}
+
+func TestSendRequestDistinct(t *testing.T) {
+	s := CsvParser{
+		tableName: "business",
+		csvModel: &CsvModel{
+			columnsName: []string{"series_reference", "period", "units"},
+			data: [][]string{
+				{"BDCQ.SF1AA2CA", "2016.06", "Dollars"},
+				{"BDCQ.SF1AA2CA", "2016.09", "Dollars"},
+				{"BDCQ.SF1AA2CB", "2016.06", "Dollars"},
+				{"BDCQ.SF1AA2CB", "2016.06", "Number"},
+				{"BDCQ.SF1AA2CA", "2016.09", "Dollars"},
+			},
+		},
+	}
+
+	testData := []struct {
+		InRequest string
+		Result    [][]string
+	}{
+		{
+			InRequest: "select distinct series_reference from business;",
+			Result:    [][]string{{"series_reference"}, {"BDCQ.SF1AA2CA"}, {"BDCQ.SF1AA2CB"}},
+		},
+		{
+			InRequest: "select distinct * from business;",
+			Result: [][]string{
+				{"BDCQ.SF1AA2CA", "2016.06", "Dollars"},
+				{"BDCQ.SF1AA2CA", "2016.09", "Dollars"},
+				{"BDCQ.SF1AA2CB", "2016.06", "Dollars"},
+				{"BDCQ.SF1AA2CB", "2016.06", "Number"},
+			},
+		},
+		{
+			InRequest: "select distinct series_reference, units from business limit 2 offset 1;",
+			Result:    [][]string{{"series_reference", "units"}, {"BDCQ.SF1AA2CB", "Dollars"}, {"BDCQ.SF1AA2CB", "Number"}},
+		},
+		{
+			InRequest: "select distinct on (series_reference) series_reference, period from business order by series_reference, period desc;",
+			Result:    [][]string{{"series_reference", "period"}, {"BDCQ.SF1AA2CA", "2016.09"}, {"BDCQ.SF1AA2CB", "2016.06"}},
+		},
+	}
+
+	p := parsing.NewParser()
+	for _, data := range testData {
+		sel, err := p.Parse(data.InRequest)
+		assert.Equal(t, err, nil)
+
+		res, err := s.SendRequest(sel)
+
+		assert.Equal(t, err, nil)
+		assert.Equal(t, res, data.Result)
+	}
+}
+
+func TestSendRequestAlias(t *testing.T) {
+	s := CsvParser{
+		tableName: "business",
+		csvModel: &CsvModel{
+			columnsName: []string{"status", "units", "magnitude"},
+			data: [][]string{
+				{"F", "Dollars", "6"},
+				{"C", "Number", "7"},
+				{"F", "Number", "9"},
+			},
+		},
+	}
+
+	testData := []struct {
+		InRequest string
+		Result    [][]string
+		Error     error
+	}{
+		{
+			InRequest: "select status as s, magnitude m from business order by m desc;",
+			Result:    [][]string{{"s", "m"}, {"F", "9"}, {"C", "7"}, {"F", "6"}},
+		},
+		{
+			InRequest: "select status st, count(*) as total from business group by st order by total desc, st;",
+			Result:    [][]string{{"st", "total"}, {"F", "2"}, {"C", "1"}},
+		},
+		{
+			// GROUP BY prefers the csv column, so rows are grouped by units, not by the alias of status
+			InRequest: "select status as units, count(*) from business group by units;",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("column 'status' must appear in the GROUP BY clause or be used in an aggregate function"),
+		},
+	}
+
+	p := parsing.NewParser()
+	for _, data := range testData {
+		sel, err := p.Parse(data.InRequest)
+		assert.Equal(t, err, nil)
+
+		res, err := s.SendRequest(sel)
+
+		assert.Equal(t, err, data.Error)
+		assert.Equal(t, res, data.Result)
+	}
+}
+
+func TestSendRequestArithmetic(t *testing.T) {
+	s := CsvParser{
+		tableName: "business",
+		csvModel: &CsvModel{
+			columnsName: []string{"data_value", "magnitude"},
+			data: [][]string{
+				{"1116.386", "6"},
+				{"70.5", "7"},
+				{"3", "3"},
+			},
+		},
+	}
+
+	testData := []struct {
+		InRequest string
+		Result    [][]string
+		Error     error
+	}{
+		{
+			InRequest: "select data_value * 1000 as dollars from business where magnitude - 3 > 2;",
+			Result:    [][]string{{"dollars"}, {"1116386"}, {"70500"}},
+		},
+		{
+			InRequest: "select magnitude / 2, magnitude % 4, -magnitude, magnitude / 2.0 from business where data_value < 100;",
+			Result:    [][]string{{"(magnitude / 2)", "(magnitude % 4)", "(-magnitude)", "(magnitude / 2.0)"}, {"3", "3", "-7", "3.5"}, {"1", "3", "-3", "1.5"}},
+		},
+		{
+			InRequest: "select 1 + 2 * 3, (1 + 2) * 3, 7 - -2;",
+			Result:    [][]string{{"(1 + (2 * 3))", "((1 + 2) * 3)", "(7 - (-2))"}, {"7", "9", "9"}},
+		},
+		{
+			InRequest: "select magnitude from business order by magnitude * -1 limit 1;",
+			Result:    [][]string{{"magnitude"}, {"7"}},
+		},
+		{
+			InRequest: "select magnitude / (magnitude - 3) from business;",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("division by zero"),
+		},
+	}
+
+	p := parsing.NewParser()
+	for _, data := range testData {
+		sel, err := p.Parse(data.InRequest)
+		assert.Equal(t, err, nil)
+
+		res, err := s.SendRequest(sel)
+
+		assert.Equal(t, err, data.Error)
+		assert.Equal(t, res, data.Result)
+	}
+}
+
+func TestSendRequestFunctions(t *testing.T) {
+	s := CsvParser{
+		tableName: "business",
+		csvModel: &CsvModel{
+			columnsName: []string{"status", "units", "magnitude"},
+			data: [][]string{
+				{"F", " Dollars ", "6"},
+				{"c", "", "-2.5"},
+			},
+		},
+	}
+
+	testData := []struct {
+		InRequest string
+		Result    [][]string
+		Error     error
+	}{
+		{
+			InRequest: "select lower(status), upper(trim(units)), length(units), substr(trim(units), 2, 3) from business where upper(status) = 'F';",
+			Result:    [][]string{{"lower(status)", "upper(trim(units))", "length(units)", "substr(trim(units), 2, 3)"}, {"f", "DOLLARS", "9", "oll"}},
+		},
+		{
+			InRequest: "select substr('abc', 2, 9223372036854775807), substr('abc', -9223372036854775807, 9223372036854775807), substr('abc', 1e300);",
+			Result: [][]string{
+				{"substr('abc', 2, 9223372036854775807)", "substr('abc', (-9223372036854775807), 9223372036854775807)", "substr('abc', 1e300)"},
+				{"bc", "", ""},
+			},
+		},
+		{
+			InRequest: "select status || '-' || magnitude s, concat(status, units, 1), coalesce(units, 'none'), nullif(status, 'f') from business where magnitude < 0;",
+			Result:    [][]string{{"s", "concat(status, units, 1)", "coalesce(units, 'none')", "nullif(status, 'f')"}, {"c--2.5", "c1", "none", "c"}},
+		},
+		{
+			InRequest: "select round(magnitude), round(magnitude / 4.0, 1), abs(magnitude), floor(magnitude), ceil(magnitude) from business;",
+			Result:    [][]string{{"round(magnitude)", "round((magnitude / 4.0), 1)", "abs(magnitude)", "floor(magnitude)", "ceil(magnitude)"}, {"6", "1.5", "6", "6", "6"}, {"-3", "-0.6", "2.5", "-3", "-2"}},
+		},
+		{
+			InRequest: "select iif(magnitude > 0, 'up', 'down'), iif(magnitude > 0, magnitude, 1 / 0) from business order by replace(status, 'c', 'a');",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("division by zero"),
+		},
+		{
+			InRequest: "select iif(magnitude > 0, 'up', 'down') from business order by replace(status, 'c', 'a');",
+			Result:    [][]string{{"iif((magnitude > 0), 'up', 'down')"}, {"down"}, {"up"}},
+		},
+		{
+			InRequest: "select status from business where length(units, 1) > 0;",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("function 'length' expects 1 argument, got 2"),
+		},
+		{
+			InRequest: "select abs('a') from business;",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("types do not match. argument 1 of 'abs' must be number, got string"),
+		},
+		{
+			InRequest: "select iif(1, status, 2) from business;",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("types do not match. argument 1 of 'iif' must be condition, got number"),
+		},
+		{
+			InRequest: "select reverse(status) from business where 1 = 0;",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("function 'reverse' does not exist"),
+		},
+	}
+
+	p := parsing.NewParser()
+	for _, data := range testData {
+		sel, err := p.Parse(data.InRequest)
+		assert.Equal(t, err, nil)
+
+		res, err := s.SendRequest(sel)
+
+		assert.Equal(t, err, data.Error)
+		assert.Equal(t, res, data.Result)
+	}
+}
+
+func TestSendRequestLike(t *testing.T) {
+	s := CsvParser{
+		tableName: "business",
+		csvModel: &CsvModel{
+			columnsName: []string{"series_title", "units"},
+			data: [][]string{
+				{"Forestry and Logging", "100%"},
+				{"logging", "10"},
+				{"Mining", "dollars"},
+			},
+		},
+	}
+
+	testData := []struct {
+		InRequest string
+		Result    [][]string
+		Error     error
+	}{
+		{
+			InRequest: "select series_title from business where series_title like '%logging%';",
+			Result:    [][]string{{"series_title"}, {"logging"}},
+		},
+		{
+			InRequest: "select series_title from business where series_title ilike '%LOGGING' and series_title not ilike 'l_g%';",
+			Result:    [][]string{{"series_title"}, {"Forestry and Logging"}},
+		},
+		{
+			InRequest: "select units from business where units like '%!%' escape '!' or units like 'd\\_%';",
+			Result:    [][]string{{"units"}, {"100%"}},
+		},
+		{
+			InRequest: "select units from business where units like '1%' and series_title like units || '%';",
+			Result:    [][]string{{"units"}},
+		},
+		{
+			InRequest: "select units from business where units like 'M_n%g' escape '' or upper(series_title) like 'M_N%G';",
+			Result:    [][]string{{"units"}, {"dollars"}},
+		},
+		{
+			InRequest: "select units from business where units like '100!' escape '!';",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("LIKE pattern must not end with escape character"),
+		},
+		{
+			InRequest: "select units from business where units like '1%' escape '!!';",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("invalid escape string '!!', it must be empty or one character"),
+		},
+		{
+			InRequest: "select units from business where units like 10;",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("types do not match. LIKE works only with strings"),
+		},
+	}
+
+	p := parsing.NewParser()
+	for _, data := range testData {
+		sel, err := p.Parse(data.InRequest)
+		assert.Equal(t, err, nil)
+
+		res, err := s.SendRequest(sel)
+
+		assert.Equal(t, err, data.Error)
+		assert.Equal(t, res, data.Result)
+	}
+}
+
+func TestSendRequestInBetween(t *testing.T) {
+	s := CsvParser{
+		tableName: "business",
+		csvModel: &CsvModel{
+			columnsName: []string{"status", "magnitude", "period"},
+			data: [][]string{
+				{"F", "6", "2016.06"},
+				{"c", "3", "2011.03"},
+				{"R", "7.5", "2020.12"},
+			},
+		},
+	}
+
+	testData := []struct {
+		InRequest string
+		Result    [][]string
+		Error     error
+	}{
+		{
+			InRequest: "select status from business where status in ('f', 'C', 'x');",
+			Result:    [][]string{{"status"}, {"F"}, {"c"}},
+		},
+		{
+			InRequest: "select status from business where magnitude in (6.0, -1, 7.5) and status not in ('c');",
+			Result:    [][]string{{"status"}, {"F"}, {"R"}},
+		},
+		{
+			InRequest: "select status from business where magnitude * 2 in ('12', 15) or status in (lower(status));",
+			Result:    [][]string{{"status"}, {"F"}, {"c"}, {"R"}},
+		},
+		{
+			InRequest: "select status from business where magnitude between 3 and 6 and period between 2011 and 2012.01 or status between 'q' and 's';",
+			Result:    [][]string{{"status"}, {"c"}, {"R"}},
+		},
+		{
+			InRequest: "select status from business where status in (1, 2);",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("types do not match. data F must be a number"),
+		},
+	}
+
+	p := parsing.NewParser()
+	for _, data := range testData {
+		sel, err := p.Parse(data.InRequest)
+		assert.Equal(t, err, nil)
+
+		res, err := s.SendRequest(sel)
+
+		assert.Equal(t, err, data.Error)
+		assert.Equal(t, res, data.Result)
+	}
+}
+
+func TestSendRequestNull(t *testing.T) {
+	s := CsvParser{
+		tableName: "business",
+		csvModel: &CsvModel{
+			columnsName: []string{"status", "suppressed", "data_value"},
+			data: [][]string{
+				{"F", "", "10"},
+				{"C", "Y", "NA"},
+				{"R", "NA", "7.5"},
+			},
+			nullValues: map[string]bool{"NA": true},
+		},
+	}
+
+	testData := []struct {
+		InRequest string
+		Result    [][]string
+		Error     error
+	}{
+		{
+			InRequest: "select status from business where suppressed is null or data_value is null;",
+			Result:    [][]string{{"status"}, {"C"}, {"R"}},
+		},
+		{
+			InRequest: "select status, data_value * 2, suppressed || '!' from business where data_value > 5;",
+			Result:    [][]string{{"status", "(data_value * 2)", "(suppressed || '!')"}, {"F", "20", "!"}, {"R", "15", ""}},
+		},
+		{
+			InRequest: "select status from business where not (data_value > 8) or suppressed in ('Y', null);",
+			Result:    [][]string{{"status"}, {"C"}, {"R"}},
+		},
+		{
+			InRequest: "select status from business where data_value is not null and not data_value between 8 and null;",
+			Result:    [][]string{{"status"}, {"R"}},
+		},
+		{
+			InRequest: "select count(*), count(data_value), sum(data_value), coalesce(max(suppressed), 'none') from business where suppressed is not null;",
+			Result:    [][]string{{"count(*)", "count(data_value)", "sum(data_value)", "coalesce(max(suppressed), 'none')"}, {"2", "1", "10", "Y"}},
+		},
+		{
+			InRequest: "select status, null is null, 1 in (2, null), upper(suppressed) from business order by data_value nulls first limit 2;",
+			Result:    [][]string{{"status", "(null is null)", "(1 in (2, null))", "upper(suppressed)"}, {"C", "true", "", "Y"}, {"R", "true", "", ""}},
+		},
+	}
+
+	p := parsing.NewParser()
+	for _, data := range testData {
+		sel, err := p.Parse(data.InRequest)
+		assert.Equal(t, err, nil)
+
+		res, err := s.SendRequest(sel)
+
+		assert.Equal(t, err, data.Error)
+		assert.Equal(t, res, data.Result)
+	}
+}
+
+func TestSendRequestRegexp(t *testing.T) {
+	s := CsvParser{
+		tableName: "business",
+		csvModel: &CsvModel{
+			columnsName: []string{"series_reference", "pattern"},
+			data: [][]string{
+				{"BDCQ.SF1AA2CA", "^BDCQ"},
+				{"BDCQ.SF1AA2CT", "CT$"},
+				{"HLFQ.S1A1S", "["},
+			},
+		},
+	}
+
+	testData := []struct {
+		InRequest string
+		Result    [][]string
+		Error     error
+	}{
+		{
+			InRequest: "select series_reference from business where series_reference ~ '^BDCQ\\.' and series_reference !~ 'CT$';",
+			Result:    [][]string{{"series_reference"}, {"BDCQ.SF1AA2CA"}},
+		},
+		{
+			InRequest: "select series_reference from business where series_reference ~* 'a2ct' or series_reference not regexp '^BD';",
+			Result:    [][]string{{"series_reference"}, {"BDCQ.SF1AA2CT"}, {"HLFQ.S1A1S"}},
+		},
+		{
+			InRequest: "select regexp_extract(series_reference, '^([A-Z]+)\\.S(\\d)?', 2), regexp_replace(series_reference, '([A-Z]+)\\.', '$1-') from business;",
+			Result: [][]string{
+				{"regexp_extract(series_reference, '^([A-Z]+)\\.S(\\d)?', 2)", "regexp_replace(series_reference, '([A-Z]+)\\.', '$1-')"},
+				{"", "BDCQ-SF1AA2CA"}, {"", "BDCQ-SF1AA2CT"}, {"1", "HLFQ-S1A1S"},
+			},
+		},
+		{
+			InRequest: "select series_reference from business where series_reference ~ pattern;",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("invalid regular expression '[': error parsing regexp: missing closing ]: `[`"),
+		},
+		{
+			InRequest: "select regexp_extract(series_reference, '(', 1) from business;",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("invalid regular expression '(': error parsing regexp: missing closing ): `(`"),
+		},
+	}
+
+	p := parsing.NewParser()
+	for _, data := range testData {
+		sel, err := p.Parse(data.InRequest)
+		assert.Equal(t, err, nil)
+
+		res, err := s.SendRequest(sel)
+
+		assert.Equal(t, err, data.Error)
+		assert.Equal(t, res, data.Result)
+	}
+}
+
+func TestSendRequestCase(t *testing.T) {
+	s := CsvParser{
+		tableName: "business",
+		csvModel: &CsvModel{
+			columnsName: []string{"status", "magnitude"},
+			data: [][]string{
+				{"F", "6"},
+				{"C", "3"},
+				{"R", "0"},
+				{"", "8"},
+			},
+		},
+	}
+
+	testData := []struct {
+		InRequest string
+		Result    [][]string
+		Error     error
+	}{
+		{
+			InRequest: "select status, case when magnitude >= 6 then 'large' when magnitude > 0 then 'small' end size from business;",
+			Result:    [][]string{{"status", "size"}, {"F", "large"}, {"C", "small"}, {"R", ""}, {"", "large"}},
+		},
+		{
+			InRequest: "select status from business where case status when 'f' then 1 = 1 when 'c' then magnitude < 5 else status is null end order by case when status is null then 0 else magnitude end;",
+			Result:    [][]string{{"status"}, {""}, {"C"}, {"F"}},
+		},
+		{
+			InRequest: "select case when magnitude >= 6 then 'large' else 'small' end, count(*) from business group by case when magnitude >= 6 then 'large' else 'small' end;",
+			Result:    [][]string{{"case when (magnitude >= 6) then 'large' else 'small' end", "count(*)"}, {"large", "2"}, {"small", "2"}},
+		},
+		{
+			InRequest: "select case when magnitude = 0 then 0 else 10 / magnitude end from business where status = 'R';",
+			Result:    [][]string{{"case when (magnitude = 0) then 0 else (10 / magnitude) end"}, {"0"}},
+		},
+		{
+			InRequest: "select case when magnitude then 1 end from business;",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("expression 'magnitude' is not a condition"),
+		},
+		{
+			InRequest: "select case when 'a' then 1 end from business;",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("types do not match. WHEN ''a'' must be a condition"),
+		},
+	}
+
+	p := parsing.NewParser()
+	for _, data := range testData {
+		sel, err := p.Parse(data.InRequest)
+		assert.Equal(t, err, nil)
+
+		res, err := s.SendRequest(sel)
+
+		assert.Equal(t, err, data.Error)
+		assert.Equal(t, res, data.Result)
+	}
+}
+
+func TestSendRequestJoin(t *testing.T) {
+	s := CsvParser{
+		tableName: "business",
+		csvModel: &CsvModel{
+			columnsName: []string{"status", "magnitude"},
+			data: [][]string{
+				{"F", "6"},
+				{"C", "3"},
+				{"r", "0"},
+				{"", "8"},
+			},
+		},
+		tables: map[string]*CsvModel{
+			"statuses": {
+				columnsName: []string{"status", "description"},
+				data: [][]string{
+					{"F", "Final"},
+					{"R", "Revised"},
+					{"P", "Provisional"},
+				},
+			},
+		},
+	}
+
+	testData := []struct {
+		InRequest string
+		Result    [][]string
+		Error     error
+	}{
+		{
+			InRequest: "select b.status, description from business b join statuses s on b.status = s.status;",
+			Result:    [][]string{{"b.status", "description"}, {"F", "Final"}, {"r", "Revised"}},
+		},
+		{
+			InRequest: "select business.status, description from business left join statuses on statuses.status = business.status and magnitude > 3;",
+			Result:    [][]string{{"business.status", "description"}, {"F", "Final"}, {"C", ""}, {"r", ""}, {"", ""}},
+		},
+		{
+			InRequest: "select b.status, s.status from business b right join statuses s on b.status = s.status order by s.status;",
+			Result:    [][]string{{"b.status", "s.status"}, {"F", "F"}, {"", "P"}, {"r", "R"}},
+		},
+		{
+			InRequest: "select b.magnitude, s.status from business b full join statuses s on b.status = s.status where b.status is null or s.status is null;",
+			Result:    [][]string{{"b.magnitude", "s.status"}, {"3", ""}, {"8", ""}, {"", "P"}},
+		},
+		{
+			InRequest: "select count(*) from business cross join statuses s where s.status != 'P';",
+			Result:    [][]string{{"count(*)"}, {"8"}},
+		},
+		{
+			InRequest: "select s.status, count(b.magnitude) from statuses s left join business b on s.status = b.status group by s.status order by s.status;",
+			Result:    [][]string{{"s.status", "count(b.magnitude)"}, {"F", "1"}, {"P", "0"}, {"R", "1"}},
+		},
+		{
+			InRequest: "select status from business join statuses on business.status = statuses.status;",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("column reference 'status' is ambiguous"),
+		},
+		{
+			InRequest: "select * from business b join statuses b on b.status = b.status;",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("table name 'b' specified more than once"),
+		},
+		{
+			InRequest: "select * from business join units u on u.status = status;",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("can`t find csv with name 'units'"),
+		},
+		{
+			InRequest: "select * from business b join statuses s on b.code = s.status;",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("no such column name 'b.code' in csv"),
+		},
+	}
+
+	p := parsing.NewParser()
+	for _, data := range testData {
+		sel, err := p.Parse(data.InRequest)
+		assert.Equal(t, err, nil)
+
+		res, err := s.SendRequest(sel)
+
+		assert.Equal(t, err, data.Error)
+		assert.Equal(t, res, data.Result)
+	}
+}
+
+func TestSendRequestSubquery(t *testing.T) {
+	s := CsvParser{
+		tableName: "business",
+		csvModel: &CsvModel{
+			columnsName: []string{"status", "magnitude"},
+			data: [][]string{
+				{"F", "6"},
+				{"C", "3"},
+				{"R", "0"},
+				{"F", "8"},
+			},
+		},
+		tables: map[string]*CsvModel{
+			"statuses": {
+				columnsName: []string{"status", "description"},
+				data: [][]string{
+					{"F", "Final"},
+					{"R", "Revised"},
+					{"P", "Provisional"},
+					{"", "Unknown"},
+				},
+			},
+		},
+	}
+
+	testData := []struct {
+		InRequest string
+		Result    [][]string
+		Error     error
+	}{
+		{
+			InRequest: "select magnitude from business where status in (select status from statuses where description != 'Final');",
+			Result:    [][]string{{"magnitude"}, {"0"}},
+		},
+		{
+			InRequest: "select magnitude from business where status not in (select status from statuses);",
+			Result:    [][]string{{"magnitude"}},
+		},
+		{
+			InRequest: "select s.status from statuses s where not exists (select 1 from business b where b.status = s.status);",
+			Result:    [][]string{{"s.status"}, {"P"}, {""}},
+		},
+		{
+			InRequest: "select status, (select description from statuses s where s.status = b.status) d from business b group by status;",
+			Result:    [][]string{{"status", "d"}, {"F", "Final"}, {"C", ""}, {"R", "Revised"}},
+		},
+		{
+			InRequest: "select t.status, t.total from (select status, sum(magnitude) total from business group by status) as t where t.total > 3 order by t.total;",
+			Result:    [][]string{{"t.status", "t.total"}, {"F", "14"}},
+		},
+		{
+			InRequest: "select (select count(*) from business), (select max(magnitude) from business where status = 'F') m;",
+			Result:    [][]string{{"(select count(*) from business)", "m"}, {"4", "8"}},
+		},
+		{
+			InRequest: "select s.description from statuses s join (select distinct status from business) b on b.status = s.status order by s.description;",
+			Result:    [][]string{{"s.description"}, {"Final"}, {"Revised"}},
+		},
+		{
+			InRequest: "select (select status from business) from statuses;",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("more than one row returned by a subquery used as an expression"),
+		},
+		{
+			InRequest: "select status from business where status in (select * from statuses);",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("subquery has too many columns"),
+		},
+		{
+			InRequest: "select status from business where exists (select 1 from statuses where code = status);",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("no such column name 'code' in csv"),
+		},
+	}
+
+	p := parsing.NewParser()
+	for _, data := range testData {
+		sel, err := p.Parse(data.InRequest)
+		assert.Equal(t, err, nil)
+
+		res, err := s.SendRequest(sel)
+
+		assert.Equal(t, err, data.Error)
+		assert.Equal(t, res, data.Result)
+	}
+}
+
+func TestSendRequestSetOperations(t *testing.T) {
+	s := CsvParser{
+		tableName: "business",
+		csvModel: &CsvModel{
+			columnsName: []string{"status", "magnitude"},
+			data: [][]string{
+				{"F", "6"},
+				{"C", "3"},
+				{"F", "6"},
+				{"", "8"},
+			},
+		},
+		tables: map[string]*CsvModel{
+			"statuses": {
+				columnsName: []string{"status", "description"},
+				data: [][]string{
+					{"F", "Final"},
+					{"R", "Revised"},
+					{"", "Unknown"},
+					{"F", "Final"},
+				},
+			},
+		},
+	}
+
+	testData := []struct {
+		InRequest string
+		Result    [][]string
+		Error     error
+	}{
+		{
+			InRequest: "select status from business union select status from statuses order by status;",
+			Result:    [][]string{{"status"}, {"C"}, {"F"}, {"R"}, {""}},
+		},
+		{
+			InRequest: "select status s from business union all select status from statuses order by s limit 3;",
+			Result:    [][]string{{"s"}, {"C"}, {"F"}, {"F"}},
+		},
+		{
+			InRequest: "select status from business intersect select status from statuses;",
+			Result:    [][]string{{"status"}, {"F"}, {""}},
+		},
+		{
+			InRequest: "select status, magnitude from business intersect all select status, 6 from statuses;",
+			Result:    [][]string{{"status", "magnitude"}, {"F", "6"}, {"F", "6"}},
+		},
+		{
+			InRequest: "select status from statuses except select status from business;",
+			Result:    [][]string{{"status"}, {"R"}},
+		},
+		{
+			InRequest: "select status from business except all select status from statuses;",
+			Result:    [][]string{{"status"}, {"C"}},
+		},
+		{
+			InRequest: "select count(*) from (select status from business union select status from statuses) t;",
+			Result:    [][]string{{"count(*)"}, {"4"}},
+		},
+		{
+			InRequest: "select * from statuses where status = 'R' union select * from statuses where status = 'R';",
+			Result:    [][]string{{"R", "Revised"}},
+		},
+		{
+			InRequest: "select status, magnitude from business union select status from statuses;",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("each UNION query must have the same number of columns"),
+		},
+		{
+			InRequest: "select * from business except select status from statuses;",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("each EXCEPT query must have the same number of columns"),
+		},
+		{
+			InRequest: "select magnitude + 1 from business intersect select upper(status) from statuses;",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("INTERSECT types number and string cannot be matched"),
+		},
+		{
+			InRequest: "select status from business union select status from statuses order by magnitude;",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("no such column name 'magnitude' in csv"),
+		},
+	}
+
+	p := parsing.NewParser()
+	for _, data := range testData {
+		sel, err := p.Parse(data.InRequest)
+		assert.Equal(t, err, nil)
+
+		res, err := s.SendRequest(sel)
+
+		assert.Equal(t, err, data.Error)
+		assert.Equal(t, res, data.Result)
+	}
+}
+
+func TestSendRequestWith(t *testing.T) {
+	s := CsvParser{
+		tableName: "business",
+		csvModel: &CsvModel{
+			columnsName: []string{"status", "magnitude"},
+			data: [][]string{
+				{"F", "6"},
+				{"C", "3"},
+				{"R", "0"},
+				{"F", "8"},
+			},
+		},
+	}
+
+	testData := []struct {
+		InRequest string
+		Result    [][]string
+		Error     error
+	}{
+		{
+			InRequest: "with totals as (select status, sum(magnitude) total from business group by status) select t.status, t.total from totals t where t.total > 0 order by t.total desc;",
+			Result:    [][]string{{"t.status", "t.total"}, {"F", "14"}, {"C", "3"}},
+		},
+		{
+			InRequest: "with f as (select * from business where status = 'F'), big as (select magnitude m from f where magnitude > 6) select m from big;",
+			Result:    [][]string{{"m"}, {"8"}},
+		},
+		{
+			InRequest: "with business as (select 'X' status) select status from business;",
+			Result:    [][]string{{"status"}, {"X"}},
+		},
+		{
+			InRequest: "with recursive t(n) as (select 1 union all select n + 1 from t where n < 4) select n, (select count(*) from business where magnitude >= n) c from t;",
+			Result:    [][]string{{"n", "c"}, {"1", "3"}, {"2", "3"}, {"3", "3"}, {"4", "2"}},
+		},
+		{
+			InRequest: "with recursive t(n) as (select 1 union select 2 union select n from t) select n from t order by n;",
+			Result:    [][]string{{"n"}, {"1"}, {"2"}},
+		},
+		{
+			InRequest: "with a as (select 1), a as (select 2) select * from a;",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("WITH query name 'a' specified more than once"),
+		},
+		{
+			InRequest: "with a(x, y) as (select status from business) select * from a;",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("WITH query 'a' has 1 columns available but 2 columns specified"),
+		},
+		{
+			InRequest: "with recursive t(n) as (select 1 union all select n + 1 from t where exists (select 1 from t)) select * from t;",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("recursive reference to query 't' must not appear within a subquery"),
+		},
+		{
+			InRequest: "with recursive t(n) as (select 1 union all select n + 1 from t) select * from t;",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("recursive query 't' does not end after 10000 steps"),
+		},
+	}
+
+	p := parsing.NewParser()
+	for _, data := range testData {
+		sel, err := p.Parse(data.InRequest)
+		assert.Equal(t, err, nil)
+
+		res, err := s.SendRequest(sel)
+
+		assert.Equal(t, err, data.Error)
+		assert.Equal(t, res, data.Result)
+	}
+}
+
+func TestSendRequestWindow(t *testing.T) {
+	s := CsvParser{
+		tableName: "business",
+		csvModel: &CsvModel{
+			columnsName: []string{"status", "period", "value"},
+			data: [][]string{
+				{"F", "2016.06", "10"},
+				{"C", "2016.06", "4"},
+				{"F", "2016.09", "15"},
+				{"C", "2016.09", "4"},
+				{"F", "2016.12", "5"},
+				{"C", "2016.12", "7"},
+			},
+			nullValues: map[string]bool{"": true},
+		},
+	}
+
+	testData := []struct {
+		InRequest string
+		Result    [][]string
+		Error     error
+	}{
+		{
+			InRequest: "select status, period, sum(value) over (partition by status order by period) from business order by status, period;",
+			Result: [][]string{
+				{"status", "period", "sum(value) over (partition by status order by period)"},
+				{"C", "2016.06", "4"}, {"C", "2016.09", "8"}, {"C", "2016.12", "15"},
+				{"F", "2016.06", "10"}, {"F", "2016.09", "25"}, {"F", "2016.12", "30"},
+			},
+		},
+		{
+			InRequest: "select status, value, row_number() over (order by value) as n, rank() over (order by value) as r, " +
+				"dense_rank() over (order by value) as d from business order by n;",
+			Result: [][]string{
+				{"status", "value", "n", "r", "d"},
+				{"C", "4", "1", "1", "1"}, {"C", "4", "2", "1", "1"}, {"F", "5", "3", "3", "2"},
+				{"C", "7", "4", "4", "3"}, {"F", "10", "5", "5", "4"}, {"F", "15", "6", "6", "5"},
+			},
+		},
+		{
+			InRequest: "select period, value - lag(value) over (order by period) as diff, lead(value, 1, 0) over (order by period) as after " +
+				"from business where status = 'F';",
+			Result: [][]string{{"period", "diff", "after"}, {"2016.06", "", "15"}, {"2016.09", "5", "5"}, {"2016.12", "-10", "0"}},
+		},
+		{
+			InRequest: "select period, avg(value) over (order by period rows between 1 preceding and 1 following) as avg, " +
+				"first_value(value) over (order by period rows 1 preceding) as prev from business where status = 'F';",
+			Result: [][]string{{"period", "avg", "prev"}, {"2016.06", "12.5", "10"}, {"2016.09", "10", "10"}, {"2016.12", "10", "15"}},
+		},
+		{
+			InRequest: "select value, count(*) over (order by value range between 1 preceding and 1 following) as near " +
+				"from business where status = 'C' order by value;",
+			Result: [][]string{{"value", "near"}, {"4", "2"}, {"4", "2"}, {"7", "1"}},
+		},
+		{
+			InRequest: "select status, sum(value * 1.5) over (partition by status order by period rows between 1 preceding and current row) as s, " +
+				"sum(value * 1e0) over (partition by status order by period rows between 1 preceding and current row) as f, " +
+				"min(value) over (partition by status order by period rows between current row and unbounded following) as m, " +
+				"max(value) over (partition by status order by period) as x from business order by status, period;",
+			Result: [][]string{
+				{"status", "s", "f", "m", "x"},
+				{"C", "6", "4", "4", "4"},
+				{"C", "12", "8", "4", "4"},
+				{"C", "16.5", "11", "7", "7"},
+				{"F", "15", "10", "5", "10"},
+				{"F", "37.5", "25", "5", "15"},
+				{"F", "30", "20", "5", "15"},
+			},
+		},
+		{
+			InRequest: "select status, count(*), sum(count(*)) over () as total from business group by status order by status;",
+			Result:    [][]string{{"status", "count(*)", "total"}, {"C", "3", "6"}, {"F", "3", "6"}},
+		},
+		{
+			InRequest: "select status, count(*) over (partition by status), count(*) over () from business where period = '2016.06';",
+			Result: [][]string{
+				{"status", "count(*) over (partition by status)", "count(*) over ()"},
+				{"F", "1", "2"},
+				{"C", "1", "2"},
+			},
+		},
+		{
+			InRequest: "select status, count(*), count(*) over () from business group by status order by status;",
+			Result:    [][]string{{"status", "count(*)", "count(*) over ()"}, {"C", "3", "2"}, {"F", "3", "2"}},
+		},
+		{
+			InRequest: "select row_number() from business;",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("window function 'row_number' requires an OVER clause"),
+		},
+		{
+			InRequest: "select status from business where row_number() over () = 1;",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("window functions are not allowed in WHERE: 'row_number() over ()'"),
+		},
+		{
+			InRequest: "select upper(status) over () from business;",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("OVER specified, but 'upper' is not a window function nor an aggregate function"),
+		},
+	}
+
+	p := parsing.NewParser()
+	for _, data := range testData {
+		sel, err := p.Parse(data.InRequest)
+		assert.Equal(t, err, nil)
+
+		res, err := s.SendRequest(sel)
+
+		assert.Equal(t, err, data.Error)
+		assert.Equal(t, res, data.Result)
+	}
+}
+
+func TestSendRequestNumeric(t *testing.T) {
+	s := CsvParser{
+		tableName: "business",
+		csvModel: &CsvModel{
+			columnsName: []string{"series", "value", "magnitude"},
+			data: [][]string{
+				{"A", "1116.386", "6.0"},
+				{"B", "1000.5", "6"},
+				{"C", "0.1", "3"},
+				{"D", "0.2", "1e1"},
+			},
+		},
+	}
+
+	testData := []struct {
+		InRequest string
+		Result    [][]string
+		Error     error
+	}{
+		{
+			InRequest: "select series from business where value > 1000.5;",
+			Result:    [][]string{{"series"}, {"A"}},
+		},
+		{
+			InRequest: "select series, magnitude from business where magnitude = 6;",
+			Result:    [][]string{{"series", "magnitude"}, {"A", "6.0"}, {"B", "6"}},
+		},
+		{
+			InRequest: "select series from business where magnitude in (6, 1e1) and value < 1000.5;",
+			Result:    [][]string{{"series"}, {"D"}},
+		},
+		{
+			InRequest: "select sum(value), avg(value), max(magnitude) from business where value < 1;",
+			Result:    [][]string{{"sum(value)", "avg(value)", "max(magnitude)"}, {"0.3", "0.15", "1e1"}},
+		},
+		{
+			InRequest: "select value * 3, value / 3 from business where series = 'C';",
+			Result:    [][]string{{"(value * 3)", "(value / 3)"}, {"0.3", "0.0333333333333333"}},
+		},
+		{
+			InRequest: "select 7 / 2, 7.0 / 2, 7 / 2e0, 9223372036854775807 + 1, -1.5 % 1, round(2.675, 2), floor(-1.5), ceil(1.2);",
+			Result: [][]string{
+				{"(7 / 2)", "(7.0 / 2)", "(7 / 2e0)", "(9223372036854775807 + 1)", "((-1.5) % 1)", "round(2.675, 2)", "floor((-1.5))", "ceil(1.2)"},
+				{"3", "3.5", "3.5", "9223372036854775808", "-0.5", "2.68", "-2", "2"},
+			},
+		},
+		{
+			InRequest: "select round(1.25, 1000), round(1.5e0, 400), round(1e300, -400), round(125, -1000);",
+			Result: [][]string{
+				{"round(1.25, 1000)", "round(1.5e0, 400)", "round(1e300, (-400))", "round(125, (-1000))"},
+				{"1.25", "1.5", "0", "0"},
+			},
+		},
+		{
+			InRequest: "select round(1.5, 1000000000);",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("round: number of digits must be between -1000 and 1000, got 1000000000"),
+		},
+		{
+			InRequest: "select series from business order by magnitude desc, value;",
+			Result:    [][]string{{"series"}, {"D"}, {"B"}, {"A"}, {"C"}},
+		},
+		{
+			InRequest: "select series from business where 'nan' = '5' or 'Inf' = 'infinity' or 'NaN' in ('5', '6');",
+			Result:    [][]string{{"series"}},
+		},
+		{
+			InRequest: "select series from business where 'NaN' = 5;",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("types do not match. data NaN must be a number"),
+		},
+		{
+			InRequest: "select series from business where magnitude > value;",
+			Result:    [][]string{{"series"}, {"C"}, {"D"}},
+		},
+		{
+			InRequest: "select series from business where value > (select max(magnitude) from business);",
+			Result:    [][]string{{"series"}, {"A"}, {"B"}},
+		},
+		{
+			InRequest: "select series from business where value between magnitude and (select max(value) from business);",
+			Result:    [][]string{{"series"}, {"A"}, {"B"}},
+		},
+		{
+			InRequest: "select series, case magnitude when (select magnitude from business where series = 'B') then 'six' else 'other' end as m from business;",
+			Result:    [][]string{{"series", "m"}, {"A", "six"}, {"B", "six"}, {"C", "other"}, {"D", "other"}},
+		},
+	}
+
+	p := parsing.NewParser()
+	for _, data := range testData {
+		sel, err := p.Parse(data.InRequest)
+		assert.Equal(t, err, nil)
+
+		res, err := s.SendRequest(sel)
+
+		assert.Equal(t, err, data.Error)
+		assert.Equal(t, res, data.Result)
+	}
+}
+
+func TestSendRequestDate(t *testing.T) {
+	period, err := parseDateFormat("YYYY.MM")
+	assert.Equal(t, err, nil)
+
+	s := CsvParser{
+		tableName: "business",
+		csvModel: &CsvModel{
+			columnsName: []string{"series", "period", "updated"},
+			data: [][]string{
+				{"A", "2016.06", "2016-07-15 10:30:00"},
+				{"B", "2016.09", "2016-10-01"},
+				{"C", "2017.03", "2017-04-02 08:00:00"},
+				{"D", "", "NA"},
+			},
+			formats:    []*dateFormat{nil, period, nil},
+			nullValues: map[string]bool{"": true, "NA": true},
+		},
+	}
+
+	testData := []struct {
+		InRequest string
+		Result    [][]string
+		Error     error
+	}{
+		{
+			InRequest: "select * from business where series in ('A', 'D');",
+			Result:    [][]string{{"A", "2016-06-01", "2016-07-15 10:30:00"}, {"D", "", ""}},
+		},
+		{
+			InRequest: "select series from business where period = '2016.06' or period = '2016-09-01';",
+			Result:    [][]string{{"series"}, {"A"}, {"B"}},
+		},
+		{
+			InRequest: "select series from business where period between '2016.09' and '2017.03' and period in ('2017.03', '2016.12');",
+			Result:    [][]string{{"series"}, {"C"}},
+		},
+		{
+			InRequest: "select series, period from business where period >= date '2016-09-01';",
+			Result:    [][]string{{"series", "period"}, {"B", "2016-09-01"}, {"C", "2017-03-01"}},
+		},
+		{
+			InRequest: "select extract(year from period), extract(quarter from period), date_trunc('year', period) from business where series = 'C';",
+			Result: [][]string{
+				{"extract(year from period)", "extract(quarter from period)", "date_trunc('year', period)"},
+				{"2017", "1", "2017-01-01"},
+			},
+		},
+		{
+			InRequest: "select series from business where updated < timestamp '2016-10-01 00:00:01';",
+			Result:    [][]string{{"series"}, {"A"}, {"B"}},
+		},
+		{
+			InRequest: "select date_trunc('hour', updated), date_add('minute', 45, updated), date_diff('day', period, updated) from business where series = 'A';",
+			Result: [][]string{
+				{"date_trunc('hour', updated)", "date_add('minute', 45, updated)", "date_diff('day', period, updated)"},
+				{"2016-07-15 10:00:00", "2016-07-15 11:15:00", "44"},
+			},
+		},
+		{
+			InRequest: "select date_add('month', 1, date '2016-01-31'), date_diff('month', date '2016-06-30', date '2016-07-01'), to_date('03/2017', 'MM/YYYY');",
+			Result: [][]string{
+				{"date_add('month', 1, date '2016-01-31')", "date_diff('month', date '2016-06-30', date '2016-07-01')", "to_date('03/2017', 'MM/YYYY')"},
+				{"2016-02-29", "1", "2017-03-01"},
+			},
+		},
+		{
+			InRequest: "select series from business order by period desc nulls last limit 2;",
+			Result:    [][]string{{"series"}, {"C"}, {"B"}},
+		},
+		{
+			InRequest: "select max(period), count(*) from business where now() > date '2020-01-01';",
+			Result:    [][]string{{"max(period)", "count(*)"}, {"2017-03-01", "4"}},
+		},
+		{
+			InRequest: "select date_add('day', 1, series) from business;",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("types do not match. data A must be a date"),
+		},
+		{
+			InRequest: "select date_add('second', 86400 * 366 * 10, timestamp '2016-01-01 10:00:00'), date_add('year', -2015, date '2016-01-01');",
+			Result: [][]string{
+				{"date_add('second', ((86400 * 366) * 10), timestamp '2016-01-01 10:00:00')", "date_add('year', (-2015), date '2016-01-01')"},
+				{"2026-01-08 10:00:00", "0001-01-01"},
+			},
+		},
+		{
+			InRequest: "select date_add('day', 9223372036854775807, date '2016-01-01');",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("date_add: date out of range, 9223372036854775807 day"),
+		},
+		{
+			InRequest: "select date_add('year', -2016, date '2016-01-01');",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("date_add: date out of range, -2016 year"),
+		},
+		{
+			InRequest: "select date '2016-13-01';",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("invalid input syntax for type date: '2016-13-01'"),
+		},
+		{
+			InRequest: "select date_trunc('decade', period) from business;",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("date_trunc: unit 'decade' is not recognized"),
+		},
+		{
+			InRequest: "select to_date(period, 'YYYY.QQ') from business;",
+			Result:    [][]string{},
+			Error:     fmt.Errorf("invalid date format 'YYYY.QQ', unknown field at 'QQ'"),
+		},
+	}
+
+	p := parsing.NewParser()
+	for _, data := range testData {
+		sel, err := p.Parse(data.InRequest)
+		assert.Equal(t, err, nil)
+
+		res, err := s.SendRequest(sel)
+
+		assert.Equal(t, err, data.Error)
+		assert.Equal(t, res, data.Result)
+	}
+}
+
+func TestSendRequestEqualityLookups(t *testing.T) {
+	s := CsvParser{
+		tableName: "business",
+		csvModel: &CsvModel{
+			columnsName: []string{"series", "magnitude", "day"},
+			data: [][]string{
+				{"A", "6.0", "2016-06-01"},
+				{"B", "6", "2016-06-02"},
+				{"C", "3", "2016-06-01 10:00:00"},
+				{"D", "1e1", ""},
+			},
+			nullValues: map[string]bool{"": true},
+		},
+		tables: map[string]*CsvModel{
+			"nums": {
+				columnsName: []string{"magnitude", "day"},
+				data: [][]string{
+					{"6", "2016-06-01 00:00:00"},
+					{"10.0", "x"},
+				},
+			},
+		},
+	}
+
+	// '=' and lookups in a hash set or a hash join find the same rows
+	testData := []struct {
+		InRequests []string
+		Rows       [][]string
+	}{
+		{
+			InRequests: []string{
+				"select series from business where magnitude = '6.0';",
+				"select series from business where magnitude in ('6.0', 'x');",
+				"select series from business where magnitude in ('6', 6.0);",
+			},
+			Rows: [][]string{{"A"}, {"B"}},
+		},
+		{
+			InRequests: []string{
+				"select series from business where magnitude = 6 or magnitude = 10;",
+				"select series from business where magnitude in (6, 10);",
+				"select series from business where magnitude in (select magnitude from nums);",
+				"select b.series from business b join nums n on b.magnitude = n.magnitude;",
+				"select b.series from business b join nums n on b.magnitude + 0 = n.magnitude;",
+			},
+			Rows: [][]string{{"A"}, {"B"}, {"D"}},
+		},
+		{
+			InRequests: []string{
+				"select series from business where day = (select day from nums where magnitude = 6);",
+				"select series from business where day in (select day from nums);",
+				"select series from business where day in ('2016-06-01 00:00:00', 'x');",
+				"select b.series from business b join nums n on b.day = n.day;",
+			},
+			Rows: [][]string{{"A"}},
+		},
+		{
+			InRequests: []string{
+				"select series from business where '001' = '1' and series = 'A';",
+				"select series from business where '001' in ('1', 'x') and series = 'A';",
+			},
+			Rows: [][]string{{"A"}},
+		},
+	}
+
+	p := parsing.NewParser()
+	for _, data := range testData {
+		for _, request := range data.InRequests {
+			sel, err := p.Parse(request)
+			assert.Equal(t, err, nil)
+
+			res, err := s.SendRequest(sel)
+
+			assert.Equal(t, err, nil)
+			assert.Equal(t, res[1:], data.Rows)
+		}
+	}
+}
+
+func TestCompareNaN(t *testing.T) {
+	nan := value{kind: floatValue, float: math.NaN()}
+
+	// NaN is above all numbers and equal to itself, so ORDER BY has a strict order
+	for _, data := range []struct {
+		Left, Right value
+		Cmp         int
+	}{
+		{Left: nan, Right: nan, Cmp: 0},
+		{Left: nan, Right: value{kind: floatValue, float: math.Inf(1)}, Cmp: 1},
+		{Left: value{kind: intValue, num: 5}, Right: nan, Cmp: -1},
+	} {
+		cmp, err := compareValues(data.Left, data.Right)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, cmp, data.Cmp)
+	}
+}
+
+// block helpers
 func CreateSelectStatementColumns(item, conditionItem []string) parsing.SelectStatement {
 	sel := parsing.SelectStatement{}
 
@@ -50,14 +1664,39 @@ func CreateSelectStatementColumns(item, conditionItem []string) parsing.SelectSt
 	}
 
 	for _, val := range conditionItem {
-		p := parsing.Conditions{
-			Literal: parsing.Token{
-				Value: val,
+		condition := &parsing.Expression{
+			Binary: &parsing.BinaryExpression{
+				A:  CreateLiteral(val, parsing.IdentifierKind),
+				B:  CreateLiteral("1", parsing.NumericKind),
+				Op: parsing.Token{Value: string(parsing.EqualsOperation), Kind: parsing.OperationKind},
 			},
+			Kind: parsing.BinaryKind,
 		}
 
-		sel.Where = append(sel.Where, p)
+		if sel.Where == nil {
+			sel.Where = condition
+			continue
+		}
+
+		sel.Where = &parsing.Expression{
+			Binary: &parsing.BinaryExpression{
+				A:  sel.Where,
+				B:  condition,
+				Op: parsing.Token{Value: string(parsing.AndKeyword), Kind: parsing.KeywordKind},
+			},
+			Kind: parsing.BinaryKind,
+		}
 	}
 
 	return sel
 }
+
+func CreateLiteral(val string, kind parsing.TokenKind) *parsing.Expression {
+	return &parsing.Expression{
+		Literal: &parsing.Token{
+			Value: val,
+			Kind:  kind,
+		},
+		Kind: parsing.LiteralKind,
+	}
+}
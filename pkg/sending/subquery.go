@@ -0,0 +1,143 @@
+package sending
+
+import (
+	"course_project/pkg/parsing"
+	"fmt"
+)
+
+// scope is the current row of an outer query, which a correlated subquery refers to.
+type scope struct {
+	model  *CsvModel
+	row    []string
+	parent *scope
+	// isUsed is set when a subquery refers to the row, then its result is not reused for other rows
+	isUsed bool
+}
+
+// lookup returns the value of the column in the nearest outer query which has it.
+func (s *scope) lookup(name string) (value, bool) {
+	for ; s != nil; s = s.parent {
+		s.isUsed = true
+
+		if idx := s.model.GetIdxColumnName(name); idx != -1 {
+			return s.model.cell(s.row, idx), true
+		}
+	}
+
+	return value{}, false
+}
+
+// subquery returns the result of the subquery for the row of c. The result of a subquery
+// which does not refer to the row is computed once per query.
+func (c *CsvParser) subquery(subquery *parsing.SelectStatement, row []string) (*CsvModel, error) {
+	if result, ok := c.compiled.subqueries[subquery]; ok {
+		return result, nil
+	}
+
+	inner := c.derive(nil)
+	inner.outer = &scope{model: c.csvModel, row: row, parent: c.outer}
+
+	result, err := inner.query(subquery)
+	if err != nil {
+		return nil, err
+	}
+
+	if !inner.outer.isUsed {
+		c.compiled.subqueries[subquery] = result
+	}
+
+	return result, nil
+}
+
+// evaluateSubquery returns the only value of a scalar subquery, NULL when it has no rows.
+func (c *CsvParser) evaluateSubquery(subquery *parsing.SelectStatement, row []string) (value, error) {
+	result, err := c.subquery(subquery, row)
+	if err != nil {
+		return value{}, err
+	}
+
+	if len(result.columnsName) != 1 {
+		return value{}, fmt.Errorf("subquery must return only one column")
+	}
+
+	switch len(result.data) {
+	case 0:
+		return value{kind: nullValue}, nil
+	case 1:
+		return result.cell(result.data[0], 0), nil
+	}
+
+	return value{}, fmt.Errorf("more than one row returned by a subquery used as an expression")
+}
+
+func (c *CsvParser) evaluateExists(subquery *parsing.SelectStatement, row []string) (value, error) {
+	result, err := c.subquery(subquery, row)
+	if err != nil {
+		return value{}, err
+	}
+
+	return value{kind: boolValue, boolean: len(result.data) != 0}, nil
+}
+
+// evaluateInSubquery checks 'val IN (SELECT ...)', the values of a subquery which is
+// computed once are looked up in a set.
+func (c *CsvParser) evaluateInSubquery(val value, exp *parsing.Expression, row []string) (value, error) {
+	result, err := c.subquery(exp.Subquery, row)
+	if err != nil {
+		return value{}, err
+	}
+
+	if len(result.columnsName) != 1 {
+		return value{}, fmt.Errorf("subquery has too many columns")
+	}
+
+	set, ok := c.compiled.inSets[exp]
+	if _, isComputedOnce := c.compiled.subqueries[exp.Subquery]; !ok && isComputedOnce {
+		set = &inSet{keys: map[string]bool{}}
+		for _, str := range result.data {
+			itemVal := result.cell(str, 0)
+			if isNull(itemVal) {
+				set.hasNull = true
+				continue
+			}
+
+			set.keys[hashKey(itemVal)] = true
+		}
+
+		c.compiled.inSets[exp] = set
+	}
+
+	if set != nil && set.accepts(val) {
+		found, err := set.contains(val)
+		if err != nil {
+			return value{}, err
+		}
+
+		if !found && set.hasNull {
+			return value{kind: nullValue}, nil
+		}
+
+		return value{kind: boolValue, boolean: found}, nil
+	}
+
+	// 'a IN (SELECT ...)' is NULL when a is not found and the subquery returns NULL
+	found := value{kind: boolValue, boolean: false}
+	for _, str := range result.data {
+		itemVal := result.cell(str, 0)
+		if isNull(itemVal) {
+			found = itemVal
+			continue
+		}
+
+		cmp, err := compareValues(val, itemVal)
+		if err != nil {
+			return value{}, err
+		}
+
+		if cmp == 0 {
+			return value{kind: boolValue, boolean: true}, nil
+		}
+	}
+
+	return found, nil
+}
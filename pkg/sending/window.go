@@ -0,0 +1,726 @@
+package sending
+
+import (
+	"course_project/pkg/parsing"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// windowFunctions are functions which are computed only over a window,
+// aggregate functions are windows too when they are called with OVER.
+var windowFunctions = map[string]scalarFunction{
+	"row_number":  {minArgs: 0, maxArgs: 0, result: numberType},
+	"rank":        {minArgs: 0, maxArgs: 0, result: numberType},
+	"dense_rank":  {minArgs: 0, maxArgs: 0, result: numberType},
+	"lag":         {minArgs: 1, maxArgs: 3, argTypes: []valueType{anyType, numberType, anyType}, result: anyType},
+	"lead":        {minArgs: 1, maxArgs: 3, argTypes: []valueType{anyType, numberType, anyType}, result: anyType},
+	"first_value": {minArgs: 1, maxArgs: 1, argTypes: []valueType{anyType}, result: anyType},
+}
+
+func isWindow(exp *parsing.Expression) bool {
+	return exp.Kind == parsing.FunctionKind && exp.Function.Over != nil
+}
+
+func bindWindow(function *parsing.FunctionCall, argTypes []valueType) (valueType, error) {
+	name := function.Name.Value
+
+	over := function.Over
+	if over.Frame != nil && over.Frame.IsRange && len(over.OrderBy) != 1 {
+		for _, bound := range []parsing.FrameBound{over.Frame.Start, over.Frame.End} {
+			if bound.Kind == parsing.Preceding || bound.Kind == parsing.Following {
+				return anyType, fmt.Errorf("RANGE with offset PRECEDING/FOLLOWING requires exactly one ORDER BY column")
+			}
+		}
+	}
+
+	switch aggregateFunction(name) {
+	case countAggregate, sumAggregate, avgAggregate, minAggregate, maxAggregate:
+		if function.Distinct {
+			return anyType, fmt.Errorf("DISTINCT is not implemented for window functions")
+		}
+
+		return bindAggregate(function, argTypes)
+	}
+
+	f, ok := windowFunctions[name]
+	if !ok {
+		if _, ok := scalarFunctions[name]; ok {
+			return anyType, fmt.Errorf("OVER specified, but '%s' is not a window function nor an aggregate function", name)
+		}
+
+		return anyType, fmt.Errorf("function '%s' does not exist", name)
+	}
+
+	if function.IsAllArgs {
+		return anyType, fmt.Errorf("%s(*) does not supported", name)
+	}
+
+	if function.Distinct {
+		return anyType, fmt.Errorf("DISTINCT is specified, but '%s' is not an aggregate function", name)
+	}
+
+	if len(argTypes) < f.minArgs || len(argTypes) > f.maxArgs {
+		return anyType, fmt.Errorf("function '%s' expects %s, got %d", name, arityString(f), len(argTypes))
+	}
+
+	for idx, t := range argTypes {
+		if !isCompatible(f.argTypes[idx], t) {
+			return anyType, fmt.Errorf("types do not match. argument %d of '%s' must be %s, got %s", idx+1, name, f.argTypes[idx], t)
+		}
+	}
+
+	if f.result == anyType {
+		return argTypes[0], nil
+	}
+
+	return f.result, nil
+}
+
+// collectWindows appends window function calls found in exp to windows, skipping
+// the ones already collected.
+func collectWindows(exp *parsing.Expression, windows []*parsing.Expression) ([]*parsing.Expression, error) {
+	if isWindow(exp) {
+		for _, child := range exp.Children() {
+			nested, err := collectWindows(child, nil)
+			if err != nil {
+				return windows, err
+			}
+
+			if len(nested) != 0 {
+				return windows, fmt.Errorf("window function calls cannot be nested: '%s'", exp)
+			}
+		}
+
+		for _, found := range windows {
+			if found.String() == exp.String() {
+				return windows, nil
+			}
+		}
+
+		return append(windows, exp), nil
+	}
+
+	var err error
+	for _, child := range exp.Children() {
+		windows, err = collectWindows(child, windows)
+		if err != nil {
+			return windows, err
+		}
+	}
+
+	return windows, nil
+}
+
+// findWindows returns window function calls of the select list, ORDER BY and DISTINCT ON,
+// the other clauses are evaluated before windows and cannot use them.
+func findWindows(request *parsing.SelectStatement) ([]*parsing.Expression, error) {
+	clauses := map[string][]*parsing.Expression{"WHERE": {}, "GROUP BY": request.GroupBy, "HAVING": {}}
+	if request.Where != nil {
+		clauses["WHERE"] = []*parsing.Expression{request.Where}
+	}
+	if request.Having != nil {
+		clauses["HAVING"] = []*parsing.Expression{request.Having}
+	}
+
+	for _, clause := range []string{"WHERE", "GROUP BY", "HAVING"} {
+		for _, exp := range clauses[clause] {
+			windows, err := collectWindows(exp, nil)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(windows) != 0 {
+				return nil, fmt.Errorf("window functions are not allowed in %s: '%s'", clause, windows[0])
+			}
+		}
+	}
+
+	exps := append([]*parsing.Expression{}, request.Item...)
+	for _, item := range request.OrderBy {
+		exps = append(exps, item.Exp)
+	}
+	exps = append(exps, request.DistinctOn...)
+
+	var windows []*parsing.Expression
+	var err error
+	for _, exp := range exps {
+		windows, err = collectWindows(exp, windows)
+		if err != nil {
+			return windows, err
+		}
+	}
+
+	return windows, nil
+}
+
+// window computes the window functions for rows and returns a parser over the rows with a column
+// for every window function, named and looked up by its text like aggregates are.
+func (c *CsvParser) window(rows [][]string, windows []*parsing.Expression) (*CsvParser, error) {
+	model := &CsvModel{
+		columnsName: append([]string{}, c.csvModel.columnsName...),
+		computed:    map[string]int{},
+		nullValues:  c.csvModel.nullValues,
+	}
+	for name, idx := range c.csvModel.computed {
+		model.computed[name] = idx
+	}
+	if c.csvModel.qualifiers != nil {
+		model.qualifiers = append([]string{}, c.csvModel.qualifiers...)
+	}
+	if c.csvModel.formats != nil {
+		model.formats = append([]*dateFormat{}, c.csvModel.formats...)
+	}
+
+	model.data = make([][]string, len(rows))
+	for idx, str := range rows {
+		model.data[idx] = append([]string{}, str...)
+	}
+
+	for _, exp := range windows {
+		values, err := c.computeWindow(exp.Function, rows, model.nullCell())
+		if err != nil {
+			return c, err
+		}
+
+		idxColumn := len(model.columnsName)
+		model.computed[exp.String()] = idxColumn
+		model.columnsName = append(model.columnsName, exp.String())
+		if model.qualifiers != nil {
+			model.qualifiers = append(model.qualifiers, "")
+		}
+		if model.formats != nil {
+			model.formats = append(model.formats, nil)
+		}
+
+		for idx := range rows {
+			model.data[idx] = append(model.data[idx], values[idx])
+		}
+	}
+
+	return c.derive(model), nil
+}
+
+// partition is rows of one PARTITION BY key in the window order, peers are rows
+// with equal ORDER BY keys: the ones from peerStart[i] to peerEnd[i] for the row i.
+type partition struct {
+	rows      [][]string
+	keys      [][]sortKey
+	numeric   []bool
+	peerStart []int
+	peerEnd   []int
+
+	// rangeStart and rangeEnd are the bounds of RANGE frames with offsets found for the last row,
+	// rangeNext is the next row checked for the end
+	rangeStart int
+	rangeEnd   int
+	rangeNext  int
+}
+
+// computeWindow returns the cell of the function for every row, nullCell is the one of NULL.
+func (c *CsvParser) computeWindow(function *parsing.FunctionCall, rows [][]string, nullCell string) ([]string, error) {
+	over := function.Over
+
+	var keys []string
+	groups := map[string][]int{}
+	for idx, str := range rows {
+		var values []string
+		for _, exp := range over.PartitionBy {
+			val, err := c.evaluate(exp, str)
+			if err != nil {
+				return nil, err
+			}
+
+			values = append(values, val.String())
+		}
+
+		key := strings.Join(values, "\x00")
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+
+		groups[key] = append(groups[key], idx)
+	}
+
+	cells := make([]string, len(rows))
+	for _, key := range keys {
+		group := make([][]string, 0, len(groups[key]))
+		for _, idx := range groups[key] {
+			group = append(group, rows[idx])
+		}
+
+		part, order, err := c.newPartition(group, over.OrderBy)
+		if err != nil {
+			return nil, err
+		}
+
+		agg := c.newFrameAggregate(function, part)
+
+		rank := 0
+		for pos := range part.rows {
+			if part.peerStart[pos] == pos {
+				rank++
+			}
+
+			val, err := c.windowValue(function, part, pos, rank, agg)
+			if err != nil {
+				return nil, err
+			}
+
+			cell := val.String()
+			if isNull(val) {
+				cell = nullCell
+			}
+
+			cells[groups[key][order[pos]]] = cell
+		}
+	}
+
+	return cells, nil
+}
+
+// newPartition sorts rows by ORDER BY of the window, order[i] is the index in rows of the i-th sorted row.
+func (c *CsvParser) newPartition(rows [][]string, orderBy []*parsing.OrderItem) (*partition, []int, error) {
+	keys, numeric, err := c.orderKeys(rows, orderBy)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	order := sortOrder(keys, numeric, orderBy)
+
+	part := &partition{numeric: numeric, peerStart: make([]int, len(rows)), peerEnd: make([]int, len(rows)), rangeEnd: -1}
+	for _, idx := range order {
+		part.rows = append(part.rows, rows[idx])
+		part.keys = append(part.keys, keys[idx])
+	}
+
+	// without ORDER BY all rows of the partition are peers
+	for pos := range part.rows {
+		part.peerStart[pos] = pos
+		if pos != 0 && compareKeys(part.keys[pos-1], part.keys[pos], numeric, orderBy) == 0 {
+			part.peerStart[pos] = part.peerStart[pos-1]
+		}
+	}
+	for pos := len(part.rows) - 1; pos >= 0; pos-- {
+		part.peerEnd[pos] = pos
+		if pos != len(part.rows)-1 && part.peerStart[pos+1] == part.peerStart[pos] {
+			part.peerEnd[pos] = part.peerEnd[pos+1]
+		}
+	}
+
+	return part, order, nil
+}
+
+// windowValue returns the function for the row pos of the partition, rank is the number of its peer group.
+// Aggregates are computed by agg when it is not nil.
+func (c *CsvParser) windowValue(function *parsing.FunctionCall, part *partition, pos, rank int, agg *frameAggregate) (value, error) {
+	switch function.Name.Value {
+	case "row_number":
+		return value{kind: intValue, num: int64(pos + 1)}, nil
+	case "rank":
+		return value{kind: intValue, num: int64(part.peerStart[pos] + 1)}, nil
+	case "dense_rank":
+		return value{kind: intValue, num: int64(rank)}, nil
+	case "lag", "lead":
+		return c.offsetValue(function, part, pos)
+	}
+
+	start, end, err := frameBounds(function.Over, part, pos)
+	if err != nil {
+		return value{}, err
+	}
+
+	if function.Name.Value == "first_value" {
+		if start > end {
+			return value{kind: nullValue}, nil
+		}
+
+		return c.evaluate(function.Args[0], part.rows[start])
+	}
+
+	var result string
+	if agg != nil {
+		result, err = agg.move(start, end)
+	} else {
+		var frame [][]string
+		if start <= end {
+			frame = part.rows[start : end+1]
+		}
+
+		result, err = c.computeAggregate(function, frame)
+	}
+	if err != nil {
+		return value{}, err
+	}
+
+	// an aggregate of no values is an empty cell
+	if result == "" {
+		return value{kind: nullValue}, nil
+	}
+
+	return value{kind: stringValue, str: result}, nil
+}
+
+// frameAggregate computes an aggregate over frames of the rows of a partition one by one.
+// Frames only move forward, so rows entering a frame are added to the aggregate and rows
+// leaving it are subtracted, instead of computing every frame again. MIN and MAX cannot
+// subtract a value, so they are accumulated only while frames start at the first row.
+type frameAggregate struct {
+	c        *CsvParser
+	function *parsing.FunctionCall
+	part     *partition
+
+	// rows from lo up to hi, not including it, are in the aggregate
+	lo, hi int
+	// nums are the values of the rows added to a sum or an average
+	nums  []value
+	count int
+
+	// a sum is exact while there are no floats; floats which are not finite
+	// are not added to float, so they can be subtracted
+	exact     *big.Rat
+	float     float64
+	floats    int
+	nonFinite int
+
+	// MIN and MAX compare numbers numerically while all values are numbers
+	numbers int
+	bestNum value
+	best    string
+	bestStr string
+}
+
+// newFrameAggregate returns nil when the function is not an aggregate or is MIN or MAX
+// over frames which do not start at the first row of the partition.
+func (c *CsvParser) newFrameAggregate(function *parsing.FunctionCall, part *partition) *frameAggregate {
+	switch aggregateFunction(function.Name.Value) {
+	case countAggregate, sumAggregate, avgAggregate:
+	case minAggregate, maxAggregate:
+		frame := function.Over.Frame
+		if frame != nil && frame.Start.Kind != parsing.UnboundedPreceding {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	return &frameAggregate{c: c, function: function, part: part, nums: make([]value, len(part.rows)), exact: new(big.Rat)}
+}
+
+// move makes the frame the rows from start to end and returns the aggregate of them,
+// an empty string when it is NULL. Neither bound may move back.
+func (a *frameAggregate) move(start, end int) (string, error) {
+	// rows before lo have already left the frame
+	for ; a.hi <= end; a.hi++ {
+		if a.hi < a.lo {
+			continue
+		}
+
+		if err := a.add(a.hi); err != nil {
+			return "", err
+		}
+	}
+
+	for ; a.lo < start; a.lo++ {
+		if a.lo < a.hi {
+			a.remove(a.lo)
+		}
+	}
+
+	return a.result(), nil
+}
+
+func (a *frameAggregate) add(pos int) error {
+	name := aggregateFunction(a.function.Name.Value)
+	if a.function.IsAllArgs {
+		a.count++
+		return nil
+	}
+
+	val, err := a.c.evaluate(a.function.Args[0], a.part.rows[pos])
+	if err != nil {
+		return err
+	}
+
+	if isNull(val) {
+		a.nums[pos] = val
+		return nil
+	}
+	a.count++
+
+	switch name {
+	case sumAggregate, avgAggregate:
+		num, ok := parseNumber(val.String())
+		if !ok {
+			return fmt.Errorf("types do not match. %s works only with numbers, got %s", name, val)
+		}
+
+		a.nums[pos] = num
+		a.addNumber(num, 1)
+	case minAggregate, maxAggregate:
+		a.addExtreme(name, val.String())
+	default:
+		a.nums[pos] = val
+	}
+
+	return nil
+}
+
+func (a *frameAggregate) remove(pos int) {
+	if a.function.IsAllArgs {
+		a.count--
+		return
+	}
+
+	num := a.nums[pos]
+	if isNull(num) {
+		return
+	}
+	a.count--
+
+	switch aggregateFunction(a.function.Name.Value) {
+	case sumAggregate, avgAggregate:
+		a.addNumber(num, -1)
+	}
+}
+
+// addNumber adds num to the sum when sign is 1 and subtracts it when sign is -1.
+func (a *frameAggregate) addNumber(num value, sign int) {
+	switch {
+	case num.kind != floatValue:
+		if sign > 0 {
+			a.exact.Add(a.exact, toDecimal(num))
+		} else {
+			a.exact.Sub(a.exact, toDecimal(num))
+		}
+	case math.IsInf(num.float, 0) || math.IsNaN(num.float):
+		a.floats += sign
+		a.nonFinite += sign
+	default:
+		a.floats += sign
+		a.float += float64(sign) * num.float
+	}
+}
+
+func (a *frameAggregate) addExtreme(name aggregateFunction, str string) {
+	isFirst := a.count == 1
+	if isFirst || isExtreme(name, strings.Compare(strings.ToLower(str), strings.ToLower(a.bestStr))) {
+		a.bestStr = str
+	}
+
+	num, ok := parseNumber(str)
+	if !ok {
+		return
+	}
+
+	if a.numbers == 0 || isExtreme(name, compareNumbers(num, a.bestNum)) {
+		a.bestNum, a.best = num, str
+	}
+	a.numbers++
+}
+
+// isExtreme reports whether a value compared with the result as cmp replaces it.
+func isExtreme(name aggregateFunction, cmp int) bool {
+	return (name == minAggregate && cmp < 0) || (name == maxAggregate && cmp > 0)
+}
+
+func (a *frameAggregate) result() string {
+	name := aggregateFunction(a.function.Name.Value)
+	if name == countAggregate {
+		return strconv.Itoa(a.count)
+	}
+
+	if a.count == 0 {
+		return ""
+	}
+
+	switch name {
+	case minAggregate, maxAggregate:
+		if a.numbers == a.count {
+			return a.best
+		}
+
+		return a.bestStr
+	case avgAggregate:
+		if a.floats == 0 {
+			return formatDecimal(new(big.Rat).Quo(a.exact, new(big.Rat).SetInt64(int64(a.count))))
+		}
+
+		return strconv.FormatFloat(a.floatSum()/float64(a.count), 'f', -1, 64)
+	}
+
+	if a.floats == 0 {
+		return integerValue(new(big.Rat).Set(a.exact)).String()
+	}
+
+	return strconv.FormatFloat(a.floatSum(), 'f', -1, 64)
+}
+
+// floatSum is the sum when the frame has floats, values which are not finite are added up
+// from the frame, as Inf - Inf cannot subtract them.
+func (a *frameAggregate) floatSum() float64 {
+	exact, _ := a.exact.Float64()
+	sum := a.float + exact
+
+	if a.nonFinite != 0 {
+		for pos := a.lo; pos < a.hi; pos++ {
+			if num := a.nums[pos]; num.kind == floatValue && (math.IsInf(num.float, 0) || math.IsNaN(num.float)) {
+				sum += num.float
+			}
+		}
+	}
+
+	return sum
+}
+
+// offsetValue is LAG and LEAD: the value of the row offset rows before or after pos,
+// the default value, NULL when it is not given, when there is no such row.
+func (c *CsvParser) offsetValue(function *parsing.FunctionCall, part *partition, pos int) (value, error) {
+	offset := 1
+	if len(function.Args) > 1 {
+		val, err := c.evaluate(function.Args[1], part.rows[pos])
+		if err != nil {
+			return value{}, err
+		}
+
+		if isNull(val) {
+			return value{kind: nullValue}, nil
+		}
+
+		num, err := toNumber(val)
+		if err != nil {
+			return value{}, err
+		}
+
+		if num.kind != intValue || num.num < 0 {
+			return value{}, fmt.Errorf("%s: offset must be a non negative integer, got %s", function.Name.Value, num)
+		}
+
+		offset = int(num.num)
+	}
+
+	target := pos - offset
+	if function.Name.Value == "lead" {
+		target = pos + offset
+	}
+
+	if target < 0 || target >= len(part.rows) {
+		if len(function.Args) > 2 {
+			return c.evaluate(function.Args[2], part.rows[pos])
+		}
+
+		return value{kind: nullValue}, nil
+	}
+
+	return c.evaluate(function.Args[0], part.rows[target])
+}
+
+// frameBounds returns positions of the first and the last rows of the frame of the row pos,
+// the frame is empty when start > end. The default frame is the partition up to the last peer
+// of the row.
+func frameBounds(over *parsing.Window, part *partition, pos int) (int, int, error) {
+	frame := over.Frame
+	if frame == nil {
+		return 0, part.peerEnd[pos], nil
+	}
+
+	start, err := frameBound(frame, frame.Start, over.OrderBy, part, pos, true)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	end, err := frameBound(frame, frame.End, over.OrderBy, part, pos, false)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if start < 0 {
+		start = 0
+	}
+	if end > len(part.rows)-1 {
+		end = len(part.rows) - 1
+	}
+
+	return start, end, nil
+}
+
+func frameBound(frame *parsing.Frame, bound parsing.FrameBound, orderBy []*parsing.OrderItem,
+	part *partition, pos int, isStart bool) (int, error) {
+	switch bound.Kind {
+	case parsing.UnboundedPreceding:
+		return 0, nil
+	case parsing.UnboundedFollowing:
+		return len(part.rows) - 1, nil
+	}
+
+	if !frame.IsRange {
+		switch bound.Kind {
+		case parsing.Preceding:
+			return pos - bound.Offset, nil
+		case parsing.Following:
+			return pos + bound.Offset, nil
+		}
+
+		return pos, nil
+	}
+
+	// offsets of a NULL key cover its peers only, like CURRENT ROW does
+	if bound.Kind == parsing.CurrentRow || part.keys[pos][0].null {
+		if isStart {
+			return part.peerStart[pos], nil
+		}
+
+		return part.peerEnd[pos], nil
+	}
+
+	if !part.numeric[0] {
+		return 0, fmt.Errorf("RANGE with offset PRECEDING/FOLLOWING requires a numeric ORDER BY column")
+	}
+
+	// distance is the signed number of values from the row in the window order
+	distance := func(idx int) float64 {
+		d := toFloat(part.keys[idx][0].num) - toFloat(part.keys[pos][0].num)
+		if orderBy[0].Desc {
+			return -d
+		}
+
+		return d
+	}
+
+	limit := float64(bound.Offset)
+	if bound.Kind == parsing.Preceding {
+		limit = -limit
+	}
+
+	// rows are sorted by the key, so the bounds of the next rows are never before the ones
+	// found for this row and the scan goes on from them; NULL keys are all at one end
+	if isStart {
+		for part.rangeStart < len(part.rows) {
+			idx := part.rangeStart
+			if !part.keys[idx][0].null && distance(idx) >= limit {
+				break
+			}
+
+			part.rangeStart++
+		}
+
+		return part.rangeStart, nil
+	}
+
+	for part.rangeNext < len(part.rows) {
+		idx := part.rangeNext
+		if !part.keys[idx][0].null {
+			if distance(idx) > limit {
+				break
+			}
+
+			part.rangeEnd = idx
+		}
+
+		part.rangeNext++
+	}
+
+	return part.rangeEnd, nil
+}
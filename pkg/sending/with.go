@@ -0,0 +1,147 @@
+package sending
+
+import (
+	"course_project/pkg/parsing"
+	"fmt"
+)
+
+// maxRecursion stops a recursive query which does not end.
+const maxRecursion = 10000
+
+// commonTables are results of WITH queries, visible to the statement, its subqueries
+// and the WITH queries after them.
+type commonTables struct {
+	tables map[string]*commonTable
+	parent *commonTables
+}
+
+type commonTable struct {
+	model *CsvModel
+	// isWorking is set for the rows of a recursive query read by its recursive part,
+	// outer is the row of the query the WITH is run for
+	isWorking bool
+	outer     *scope
+}
+
+func (t *commonTables) lookup(name string) (*commonTable, bool) {
+	for ; t != nil; t = t.parent {
+		if table, ok := t.tables[name]; ok {
+			return table, true
+		}
+	}
+
+	return nil, false
+}
+
+// withTables returns the parser which sees results of the WITH queries of the statement.
+func (c *CsvParser) withTables(with *parsing.With) (*CsvParser, error) {
+	inner := c.derive(c.csvModel)
+	inner.with = &commonTables{tables: map[string]*commonTable{}, parent: c.with}
+
+	for _, table := range with.Tables {
+		if _, ok := inner.with.tables[table.Name]; ok {
+			return nil, fmt.Errorf("WITH query name '%s' specified more than once", table.Name)
+		}
+
+		var result *CsvModel
+		var err error
+		if with.Recursive && table.Select.Compound != nil && table.Select.Compound.Kind == parsing.Union {
+			result, err = inner.recursiveQuery(table)
+		} else {
+			result, err = inner.query(table.Select)
+			if err == nil {
+				result, err = renameColumns(result, table)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		inner.with.tables[table.Name] = &commonTable{model: result}
+	}
+
+	return inner, nil
+}
+
+// recursiveQuery runs 'anchor UNION [ALL] recursive part': the recursive part reads the rows
+// found by the previous step until it finds no new rows.
+func (c *CsvParser) recursiveQuery(table *parsing.CommonTable) (*CsvModel, error) {
+	compound := table.Select.Compound
+	if len(table.Select.OrderBy) != 0 || table.Select.IsLimited || table.Select.Offset != 0 {
+		return nil, fmt.Errorf("ORDER BY and LIMIT in a recursive query are not supported")
+	}
+
+	anchor, err := c.query(compound.Left)
+	if err != nil {
+		return nil, err
+	}
+
+	anchor, err = renameColumns(anchor, table)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CsvModel{columnsName: anchor.columnsName, nullValues: anchor.nullValues}
+
+	seen := newRowSet()
+	addRows := func(rows [][]string) [][]string {
+		if compound.All {
+			result.data = append(result.data, rows...)
+			return rows
+		}
+
+		var added [][]string
+		for _, row := range rows {
+			if seen.add(row) {
+				added = append(added, row)
+			}
+		}
+		result.data = append(result.data, added...)
+
+		return added
+	}
+
+	working := addRows(anchor.data)
+	for step := 0; len(working) != 0; step++ {
+		if step == maxRecursion {
+			return nil, fmt.Errorf("recursive query '%s' does not end after %d steps", table.Name, maxRecursion)
+		}
+
+		c.with.tables[table.Name] = &commonTable{
+			model:     &CsvModel{columnsName: result.columnsName, data: working, nullValues: result.nullValues},
+			isWorking: true,
+			outer:     c.outer,
+		}
+
+		next, err := c.query(compound.Right)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(next.columnsName) != len(result.columnsName) {
+			return nil, fmt.Errorf("each UNION query must have the same number of columns")
+		}
+
+		working = addRows(next.data)
+	}
+	delete(c.with.tables, table.Name)
+
+	return result, nil
+}
+
+// renameColumns applies 'name(column, ...)' of a WITH query to its result.
+func renameColumns(result *CsvModel, table *parsing.CommonTable) (*CsvModel, error) {
+	if len(table.Columns) == 0 {
+		return result, nil
+	}
+
+	if len(table.Columns) != len(result.columnsName) {
+		return nil, fmt.Errorf("WITH query '%s' has %d columns available but %d columns specified",
+			table.Name, len(result.columnsName), len(table.Columns))
+	}
+
+	renamed := *result
+	renamed.columnsName = table.Columns
+
+	return &renamed, nil
+}